@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var (
+	checkAll         bool
+	checkTimeout     time.Duration
+	checkParallelism int
+	checkOutput      string
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [context...]",
+	Short: "Check that the clusters of contexts are reachable and ready",
+	Long: `Contact the API server of the given contexts, the current context by
+default or every context with --all, using their certificate authority,
+client certificate or token. kubec calls /version and /readyz and reports
+the latency, the Kubernetes version and TLS or authentication errors.
+
+Clusters are checked concurrently, at most --parallel at a time and each
+within --timeout. kubec exits with status 1 when a cluster is not ready.`,
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: completeContexts(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if checkAll && len(args) > 0 {
+			return usageErrorf("give context names or --all, not both")
+		}
+		if checkTimeout <= 0 {
+			return usageErrorf("--timeout must be positive")
+		}
+		if checkParallelism < 1 {
+			return usageErrorf("--parallel must be at least 1")
+		}
+		if checkOutput != "text" && checkOutput != "json" {
+			return usageErrorf("unknown output format '%s', use text or json", checkOutput)
+		}
+
+		config, err := kube.Config(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		names := args
+		switch {
+		case checkAll:
+			for _, context := range config.Contexts {
+				names = append(names, context.Name)
+			}
+			sort.Strings(names)
+		case len(names) == 0:
+			if config.CurrentContext == "" {
+				return fmt.Errorf("no current context is set")
+			}
+			names = []string{config.CurrentContext}
+		}
+		for _, name := range names {
+			if config.FindContext(name) == nil {
+				return &utils.ContextNotFoundError{Name: name}
+			}
+		}
+
+		checks := utils.CheckClusters(cmd.Context(), config, names, checkTimeout, checkParallelism)
+
+		if checkOutput == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(checks); err != nil {
+				return fmt.Errorf("failed to write checks: %w", err)
+			}
+		} else {
+			printChecks(checks)
+		}
+
+		for _, check := range checks {
+			if !check.Healthy() {
+				return exitStatus(exitFailure)
+			}
+		}
+		return nil
+	},
+}
+
+func printChecks(checks []utils.ClusterCheck) {
+	width := 0
+	for _, check := range checks {
+		if len(check.Context) > width {
+			width = len(check.Context)
+		}
+	}
+
+	for _, check := range checks {
+		status := color.GreenString("✓")
+		if !check.Healthy() {
+			status = color.RedString("✗")
+		}
+
+		// Without a client no request was made
+		latency := "-"
+		if check.ErrorKind != utils.CheckErrorConfig {
+			latency = fmt.Sprintf("%dms", check.LatencyMS)
+		}
+		version := check.Version
+		if version == "" {
+			version = "-"
+		}
+
+		line := fmt.Sprintf("%s %-*s  %7s  %-10s", status, width, check.Context, latency, version)
+		if check.Error != "" {
+			line += "  " + color.RedString("%s: %s", check.ErrorKind, check.Error)
+		} else {
+			line += "  " + check.Server
+		}
+		fmt.Println(line)
+	}
+}
+
+func init() {
+	checkCmd.Flags().BoolVarP(&checkAll, "all", "A", false, "Check every context")
+	checkCmd.Flags().DurationVar(&checkTimeout, "timeout", 5*time.Second, "Time allowed for each cluster")
+	checkCmd.Flags().IntVarP(&checkParallelism, "parallel", "p", 8, "Number of clusters checked at the same time")
+	checkCmd.Flags().StringVarP(&checkOutput, "output", "o", "text", "Output format: text or json")
+	registerFlagValues(checkCmd, "output", "text", "json")
+	rootCmd.AddCommand(checkCmd)
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckRejectsNonPositiveTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { checkTimeout = timeout }(checkTimeout)
+
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		checkTimeout = timeout
+		if err := checkCmd.RunE(checkCmd, nil); exitCode(err) != exitUsage {
+			t.Errorf("Expected a usage error for --timeout %s, but got %v", timeout, err)
+		}
+	}
+}
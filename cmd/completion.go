@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+// Completion functions run on every <TAB>. They must never exit or print:
+// stdout carries the candidates, so failures only go to cobra's debug log.
+
+// completeContexts completes context names, described by their cluster and
+// namespace, for the first maxArgs arguments (0 for any number)
+func completeContexts(maxArgs int) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if maxArgs > 0 && len(args) >= maxArgs {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		mode := sortMode
+		if !utils.IsSortMode(mode) {
+			mode = utils.SortMRU
+		}
+		contexts, err := kube.SortedContexts(cmd.Context(), mode)
+		if err != nil {
+			cobra.CompDebugln(fmt.Sprintf("failed to list contexts: %v", err), false)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var completions []cobra.Completion
+		for _, context := range contexts {
+			if !strings.HasPrefix(context.Name, toComplete) || containsArg(args, context.Name) {
+				continue
+			}
+			completions = append(completions, cobra.CompletionWithDesc(context.Name, describeCompletion(context)))
+		}
+
+		// Most recently used first, the shell must not sort them again
+		return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+	}
+}
+
+func describeCompletion(context utils.ContextSummary) string {
+	namespace := context.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	return fmt.Sprintf("cluster %s, namespace %s", context.Cluster, namespace)
+}
+
+// completeNamespaces completes the namespaces known for the current context.
+// The cluster is not contacted, the list comes from what kubec remembers.
+func completeNamespaces(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	namespaces, current, err := kube.NamespaceCandidates(cmd.Context())
+	if err != nil {
+		cobra.CompDebugln(fmt.Sprintf("failed to list namespaces: %v", err), false)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []cobra.Completion
+	for _, namespace := range namespaces {
+		if !strings.HasPrefix(namespace, toComplete) {
+			continue
+		}
+		if namespace == current {
+			completions = append(completions, cobra.CompletionWithDesc(namespace, "current"))
+		} else {
+			completions = append(completions, namespace)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func containsArg(args []string, value string) bool {
+	for _, arg := range args {
+		if arg == value {
+			return true
+		}
+	}
+	return false
+}
+
+// registerFlagValues completes a flag with a fixed set of values
+func registerFlagValues(cmd *cobra.Command, flag string, values ...string) {
+	cmd.RegisterFlagCompletionFunc(flag, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp))
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCompleteContexts(t *testing.T) {
+	writeTestKubeConfig(t, `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: c1
+  cluster:
+    server: https://c1.example.com
+contexts:
+- name: dev
+  context:
+    cluster: c1
+    namespace: app
+- name: prod
+  context:
+    cluster: c1
+`)
+	sortMode = "alpha"
+	defer func() { sortMode = defaultSortMode() }()
+
+	completions, directive := completeContexts(0)(rootCmd, nil, "")
+	expected := []cobra.Completion{"dev\tcluster c1, namespace app", "prod\tcluster c1, namespace default"}
+	if !reflect.DeepEqual(completions, expected) {
+		t.Errorf("Expected %q, got %q", expected, completions)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveKeepOrder {
+		t.Errorf("Unexpected directive %d", directive)
+	}
+
+	// Names already given and names without the typed prefix are left out
+	completions, _ = completeContexts(0)(deleteCmd, []string{"dev"}, "")
+	if len(completions) != 1 || completions[0] != "prod\tcluster c1, namespace default" {
+		t.Errorf("Expected only prod, got %q", completions)
+	}
+	completions, _ = completeContexts(0)(deleteCmd, nil, "p")
+	if len(completions) != 1 {
+		t.Errorf("Expected only prod, got %q", completions)
+	}
+
+	// Only the first argument of rename is a context
+	if completions, _ := completeContexts(1)(renameCmd, []string{"dev"}, ""); len(completions) != 0 {
+		t.Errorf("Expected no completions for the new name, got %q", completions)
+	}
+}
+
+func TestCompleteBrokenKubeConfig(t *testing.T) {
+	writeTestKubeConfig(t, "contexts: [\n")
+
+	completions, directive := completeContexts(1)(rootCmd, nil, "")
+	if len(completions) != 0 || directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("Expected no completions, got %q with directive %d", completions, directive)
+	}
+	completions, directive = completeNamespaces(nsCmd, nil, "")
+	if len(completions) != 0 || directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("Expected no completions, got %q with directive %d", completions, directive)
+	}
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var copyNamespace string
+
+var copyCmd = &cobra.Command{
+	Use:               "copy <source> <destination>",
+	Short:             "Copy a context under a new name",
+	Long:              `Copy a context under a new name, optionally pointing the copy at another namespace.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeContexts(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source, destination := args[0], args[1]
+		if strings.TrimSpace(destination) == "" {
+			return usageErrorf("the destination context name must not be empty")
+		}
+
+		err := kube.CopyContext(cmd.Context(), source, destination, copyNamespace)
+		if err != nil {
+			return fmt.Errorf("failed to copy context: %w", err)
+		}
+
+		fmt.Printf("Copied context '%s' to '%s'\n", source, color.GreenString(destination))
+		return nil
+	},
+}
+
+func init() {
+	copyCmd.Flags().StringVarP(&copyNamespace, "namespace", "n", "", "Namespace of the new context")
+	rootCmd.AddCommand(copyCmd)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var currentOutput string
+
+var currentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Print the current context in a machine-readable format",
+	Long: `Print the current context. -o name, the default, prints only its name,
+json, yaml, table and wide describe it the same way as kubec list.
+
+kubec exits with status 1 when no current context is set.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := kube.Config(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if config.CurrentContext == "" {
+			return fmt.Errorf("no current context is set")
+		}
+
+		// The name is known even when the context is not defined
+		if currentOutput == "name" {
+			fmt.Println(config.CurrentContext)
+			return nil
+		}
+
+		context := config.FindContext(config.CurrentContext)
+		if context == nil {
+			return &utils.ContextNotFoundError{Name: config.CurrentContext}
+		}
+		item := contextListItem{ContextSummary: config.Describe(*context), Current: true}
+		return writeContexts(os.Stdout, currentOutput, []contextListItem{item}, item)
+	},
+}
+
+func init() {
+	currentCmd.Flags().StringVarP(&currentOutput, "output", "o", "name", "Output format: name, json, yaml, wide or table")
+	registerFlagValues(currentCmd, "output", contextOutputs...)
+	rootCmd.AddCommand(currentCmd)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var deleteYes bool
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete <name>...",
+	Short: "Delete contexts",
+	Long: `Delete one or more contexts.
+
+Clusters and users that are no longer referenced by any context afterwards
+can be removed as well. kubec asks before removing them, --yes removes them
+without asking.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeContexts(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		currentContext, err := kube.CurrentContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		var orphanClusters, orphanUsers []string
+		for _, contextName := range args {
+			clusters, users, err := kube.DeleteContext(cmd.Context(), contextName)
+			if err != nil {
+				return fmt.Errorf("failed to delete context: %w", err)
+			}
+			orphanClusters = append(orphanClusters, clusters...)
+			orphanUsers = append(orphanUsers, users...)
+
+			fmt.Printf("Deleted context '%s'\n", color.RedString(contextName))
+			if contextName == currentContext {
+				utils.PrintWarning(fmt.Sprintf("'%s' was the current context, switch to another one with kubec", contextName))
+			}
+		}
+
+		if len(orphanClusters) == 0 && len(orphanUsers) == 0 {
+			return nil
+		}
+
+		fmt.Println("No context uses these entries anymore:")
+		for _, cluster := range orphanClusters {
+			fmt.Printf("  cluster %s\n", cluster)
+		}
+		for _, user := range orphanUsers {
+			fmt.Printf("  user    %s\n", user)
+		}
+
+		if !deleteYes && !(isInteractive() && confirm("Remove them")) {
+			fmt.Println("Kept unused clusters and users")
+			return nil
+		}
+
+		err = kube.DeleteClusters(cmd.Context(), orphanClusters)
+		if err != nil {
+			return fmt.Errorf("failed to delete clusters: %w", err)
+		}
+		err = kube.DeleteUsers(cmd.Context(), orphanUsers)
+		if err != nil {
+			return fmt.Errorf("failed to delete users: %w", err)
+		}
+
+		fmt.Printf("Removed %s\n", strings.Join(append(prefixAll("cluster ", orphanClusters), prefixAll("user ", orphanUsers)...), ", "))
+		return nil
+	},
+}
+
+func prefixAll(prefix string, values []string) []string {
+	result := make([]string, len(values))
+	for i, value := range values {
+		result[i] = prefix + value
+	}
+	return result
+}
+
+func init() {
+	deleteCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "Remove unused clusters and users without asking")
+	rootCmd.AddCommand(deleteCmd)
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/chzyer/readline"
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var (
+	envShell string
+	envUnset bool
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env <context>",
+	Short: "Print shell commands that start a session for a context",
+	Long: `Print shell commands that start a kubec session in the current shell:
+
+  eval "$(kubec env prod)"
+
+With the shell integration (see kubec init) "kubec env prod" is enough.
+
+In a session KUBECONFIG starts with a private kubeconfig file that sets the
+current context, followed by your usual files. Switching contexts or
+namespaces with kubec then only affects this shell. The session ends with
+
+  eval "$(kubec env --unset)"
+
+or is cleaned up by the next kubec session after the shell has exited.
+Outside Unix kubec cannot tell whether the shell has exited, so there a
+session is cleaned up once no context was switched in it for 24 hours; a
+shell still using it then falls back to your usual current context.`,
+	ValidArgsFunction: completeContexts(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if envUnset {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var changes []utils.EnvChange
+		if envUnset {
+			if session := utils.CurrentSession(); session != "" {
+				if err := utils.RemoveSession(session); err != nil {
+					return fmt.Errorf("failed to end session: %w", err)
+				}
+			}
+			changes = utils.EndSessionEnv()
+
+			if utils.ShellHookActive() && readline.IsTerminal(int(os.Stdout.Fd())) {
+				if _, err := utils.WriteShellHook(changes); err != nil {
+					return fmt.Errorf("failed to end session: %w", err)
+				}
+				fmt.Println("Session ended")
+				return nil
+			}
+		} else {
+			// stdout may be evaluated by the shell, so ask on stderr
+			if err := confirmSessionSwitch(cmd.Context(), os.Stderr, args[0]); err != nil {
+				return err
+			}
+
+			// Run directly under the shell integration, start the session
+			// through the hook instead of printing commands
+			if utils.ShellHookActive() && readline.IsTerminal(int(os.Stdout.Fd())) {
+				return startSession(cmd.Context(), args[0], utils.ShellPID())
+			}
+
+			// The session lives as long as the shell that evaluates the output
+			session, err := kube.CreateSession(cmd.Context(), args[0], utils.ShellPID())
+			if err != nil {
+				return fmt.Errorf("failed to start session: %w", err)
+			}
+			// Replace the session this shell had before
+			if previous := utils.CurrentSession(); previous != "" {
+				utils.RemoveSession(previous)
+			}
+			changes = session.Env()
+		}
+
+		output, err := utils.FormatEnv(envShell, changes)
+		if err != nil {
+			return fmt.Errorf("failed to print environment: %w", err)
+		}
+		fmt.Print(output)
+
+		// Printed to a terminal the commands do nothing, tell how to apply them
+		if readline.IsTerminal(int(os.Stdout.Fd())) {
+			command := "kubec env --unset"
+			if !envUnset {
+				command = "kubec env " + args[0]
+			}
+			fmt.Fprintf(os.Stderr, "# Apply with: eval \"$(%s)\"\n", command)
+		}
+		return nil
+	},
+}
+
+func init() {
+	envCmd.Flags().StringVar(&envShell, "shell", utils.DetectShell(), "Shell syntax to print: bash, zsh or fish")
+	envCmd.Flags().BoolVar(&envUnset, "unset", false, "End the session of this shell")
+	envCmd.Flags().BoolVarP(&switchYes, "yes", "y", false, "Start a session for a protected context without typing its name")
+	registerFlagValues(envCmd, "shell", utils.Shells...)
+	rootCmd.AddCommand(envCmd)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEnvConfirmsProtectedContext(t *testing.T) {
+	writeTestKubeConfig(t, `apiVersion: v1
+kind: Config
+current-context: dev
+contexts:
+- name: dev
+  context:
+    cluster: c1
+- name: prod
+  context:
+    cluster: c1
+`)
+	writeTestSettings(t, "contexts:\n- match: prod\n  protected: true\n")
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+	envCmd.SetContext(context.Background())
+
+	// Tests have no terminal to type the name into
+	err := envCmd.RunE(envCmd, []string{"prod"})
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("Expected the session to need --yes, got %v", err)
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("Expected no session to be created, found %v", entries)
+	}
+
+	switchYes = true
+	defer func() { switchYes = false }()
+	if err := envCmd.RunE(envCmd, []string{"prod"}); err != nil {
+		t.Fatalf("failed to start session with --yes: %v", err)
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 1 {
+		t.Errorf("Expected a session to be created, found %v", entries)
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+// Exit codes of kubec, documented in the README. lint and expiry also exit
+// with exitFailure when they find a problem.
+const (
+	exitFailure            = 1
+	exitUsage              = 2
+	exitKubeconfigNotFound = 3
+	exitKubeconfigInvalid  = 4
+	exitContextNotFound    = 5
+)
+
+// usageError is an invalid flag or argument
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string {
+	return e.err.Error()
+}
+
+func (e usageError) Unwrap() error {
+	return e.err
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// exitStatus ends kubec with a status and no further message, the command
+// has already reported what went wrong
+type exitStatus int
+
+func (s exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
+
+// exitCode maps an error returned by a command to the exit status of kubec
+func exitCode(err error) int {
+	var status exitStatus
+	var usage usageError
+	var parseErr *utils.ParseError
+	switch {
+	case errors.As(err, &status):
+		return int(status)
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, utils.ErrKubeconfigNotFound):
+		return exitKubeconfigNotFound
+	case errors.As(err, &parseErr):
+		return exitKubeconfigInvalid
+	case errors.Is(err, utils.ErrContextNotFound):
+		return exitContextNotFound
+	default:
+		return exitFailure
+	}
+}
+
+// markUsageErrors makes argument validation errors of cmd and its
+// subcommands usage errors, flag errors are handled by SetFlagErrorFunc
+func markUsageErrors(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return usageError{err}
+			}
+			return nil
+		}
+	}
+	for _, subcommand := range cmd.Commands() {
+		markUsageErrors(subcommand)
+	}
+}
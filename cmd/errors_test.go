@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{errors.New("failed"), exitFailure},
+		{usageErrorf("unknown output format '%s'", "xml"), exitUsage},
+		{fmt.Errorf("failed to load kubeconfig: %w", utils.ErrKubeconfigNotFound), exitKubeconfigNotFound},
+		{fmt.Errorf("failed to load kubeconfig: %w", &utils.ParseError{Path: "config", Line: 3, Err: errors.New("bad")}), exitKubeconfigInvalid},
+		{fmt.Errorf("failed to rename context: %w", &utils.ContextNotFoundError{Name: "dev"}), exitContextNotFound},
+		{exitStatus(130), 130},
+	}
+
+	for _, test := range tests {
+		if code := exitCode(test.err); code != test.expected {
+			t.Errorf("Expected exit code %d for %v, but got %d", test.expected, test.err, code)
+		}
+	}
+}
+
+func TestMarkUsageErrors(t *testing.T) {
+	parent := &cobra.Command{Use: "parent"}
+	child := &cobra.Command{Use: "child", Args: cobra.ExactArgs(1)}
+	parent.AddCommand(child)
+	markUsageErrors(parent)
+
+	if err := child.Args(child, nil); exitCode(err) != exitUsage {
+		t.Errorf("Expected a usage error, but got %v", err)
+	}
+	if err := child.Args(child, []string{"one"}); err != nil {
+		t.Errorf("Expected valid arguments, but got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var (
+	expiryWarnWithin string
+	expiryOutput     string
+)
+
+var expiryCmd = &cobra.Command{
+	Use:   "expiry",
+	Short: "Show when certificates and tokens expire",
+	Long: `Show when the client certificates, certificate authorities and JWT tokens
+of every context expire, soonest first. Everything is read from the
+kubeconfig and certificate files, no cluster is contacted and token
+signatures are not verified.
+
+kubec exits with status 1 when a credential expires within --warn-within
+(days can be given as e.g. 14d), has already expired or cannot be read.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		warnWithin, err := utils.ParseDuration(expiryWarnWithin)
+		if err != nil {
+			return usageErrorf("invalid --warn-within: %w", err)
+		}
+
+		expiries, err := kube.CredentialExpiries(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to read credentials: %w", err)
+		}
+
+		now := time.Now()
+		switch expiryOutput {
+		case "json":
+			if expiries == nil {
+				expiries = []utils.CredentialExpiry{}
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(expiries); err != nil {
+				return fmt.Errorf("failed to write expiries: %w", err)
+			}
+		case "text":
+			printExpiries(expiries, now, warnWithin)
+		default:
+			return usageErrorf("unknown output format '%s', use text or json", expiryOutput)
+		}
+
+		for _, expiry := range expiries {
+			if expiry.Error != "" || expiry.NotAfter.Before(now.Add(warnWithin)) {
+				return exitStatus(exitFailure)
+			}
+		}
+		return nil
+	},
+}
+
+func printExpiries(expiries []utils.CredentialExpiry, now time.Time, warnWithin time.Duration) {
+	if len(expiries) == 0 {
+		fmt.Println("No certificates or tokens with an expiry date found")
+		return
+	}
+
+	width := 0
+	for _, expiry := range expiries {
+		if len(expiry.Context) > width {
+			width = len(expiry.Context)
+		}
+	}
+
+	for _, expiry := range expiries {
+		credential := expiry.Credential
+		if expiry.Subject != "" {
+			credential += fmt.Sprintf(" (%s)", expiry.Subject)
+		}
+
+		var date, status string
+		switch {
+		case expiry.Error != "":
+			date = "unreadable"
+			status = color.RedString(expiry.Error)
+		case expiry.Expired(now):
+			date = expiry.NotAfter.Local().Format("2006-01-02")
+			status = color.RedString("expired %s ago", formatDuration(now.Sub(expiry.NotAfter)))
+		case expiry.NotAfter.Before(now.Add(warnWithin)):
+			date = expiry.NotAfter.Local().Format("2006-01-02")
+			status = color.YellowString("expires in %s", formatDuration(expiry.NotAfter.Sub(now)))
+		default:
+			date = expiry.NotAfter.Local().Format("2006-01-02")
+			status = color.GreenString("expires in %s", formatDuration(expiry.NotAfter.Sub(now)))
+		}
+
+		fmt.Printf("%-10s  %-*s  %s  %s\n", date, width, expiry.Context, credential, status)
+	}
+}
+
+// formatDuration rounds a duration to days, hours or minutes
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+func init() {
+	expiryCmd.Flags().StringVar(&expiryWarnWithin, "warn-within", "14d", "Fail when a credential expires within this duration, e.g. 14d or 12h")
+	expiryCmd.Flags().StringVarP(&expiryOutput, "output", "o", "text", "Output format: text or json")
+	registerFlagValues(expiryCmd, "output", "text", "json")
+	rootCmd.AddCommand(expiryCmd)
+}
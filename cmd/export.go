@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var (
+	exportOptions utils.ExportOptions
+	exportFile    string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [context]...",
+	Short: "Export contexts as a self-contained kubeconfig",
+	Long: `Export contexts together with only the clusters and users they use, e.g.
+to hand a single context to a CI job or a teammate.
+
+Without a context name every context is exported, or only the current one
+with --minify. --flatten inlines certificate and key files into the
+*-data fields, and token files into token, so the result works on another
+machine. The kubeconfig is printed, or written with 0600 permissions to the
+file given with --file.`,
+	ValidArgsFunction: completeContexts(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !utils.IsExportFormat(exportOptions.Format) {
+			return usageErrorf("unknown output format '%s', use %s", exportOptions.Format, strings.Join(utils.ExportFormats, ", "))
+		}
+
+		data, err := kube.Export(cmd.Context(), args, exportOptions)
+		if err != nil {
+			return fmt.Errorf("failed to export kubeconfig: %w", err)
+		}
+
+		if exportFile == "" {
+			os.Stdout.Write(data)
+			return nil
+		}
+
+		err = utils.WriteFileAtomic(exportFile, data, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", exportFile, err)
+		}
+		fmt.Printf("Exported kubeconfig to '%s'\n", color.GreenString(exportFile))
+		return nil
+	},
+}
+
+func init() {
+	exportCmd.Flags().BoolVar(&exportOptions.Flatten, "flatten", false, "Inline certificate, key and token files")
+	exportCmd.Flags().BoolVar(&exportOptions.Minify, "minify", false, "Export only the current context when no context is named")
+	exportCmd.Flags().StringVarP(&exportOptions.Format, "output", "o", "yaml", "Output format: yaml, json or base64")
+	registerFlagValues(exportCmd, "output", utils.ExportFormats...)
+	exportCmd.Flags().StringVarP(&exportFile, "file", "f", "", "Write to this file instead of stdout")
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,13 @@
+package cmd
+
+import "testing"
+
+func TestExportRejectsUnknownFormat(t *testing.T) {
+	writeTestKubeConfig(t, "contexts:\n- name: dev\n")
+	defer func(format string) { exportOptions.Format = format }(exportOptions.Format)
+
+	exportOptions.Format = "toml"
+	if err := exportCmd.RunE(exportCmd, nil); exitCode(err) != exitUsage {
+		t.Errorf("Expected a usage error for -o toml, but got %v", err)
+	}
+}
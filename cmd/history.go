@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var historyLimit int
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recent context switches",
+	Long:  `Show recent context switches made with kubec, newest first. Use "kubec -" to go back to the previous context.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := utils.GetHistory()
+		if err != nil {
+			return fmt.Errorf("failed to read history: %w", err)
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("No context switches recorded yet")
+			return nil
+		}
+
+		shown := 0
+		for i := len(entries) - 1; i >= 0 && (historyLimit <= 0 || shown < historyLimit); i-- {
+			entry := entries[i]
+			line := fmt.Sprintf("%s  %s", entry.Time.Local().Format("2006-01-02 15:04:05"), color.GreenString(entry.Context))
+			if entry.Previous != "" {
+				line += fmt.Sprintf(" (from %s)", entry.Previous)
+			}
+			fmt.Println(line)
+			shown++
+		}
+		return nil
+	},
+}
+
+func init() {
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of entries to show (0 for all)")
+	rootCmd.AddCommand(historyCmd)
+}
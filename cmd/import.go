@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var importStrategy string
+
+var importCmd = &cobra.Command{
+	Use:   "import <file|->",
+	Short: "Merge another kubeconfig into yours",
+	Long: `Merge the clusters, users and contexts of a kubeconfig file, or of stdin
+with "-", into the active kubeconfig. Base64 encoded input, as stored in CI
+secrets, is decoded automatically.
+
+Entries that already exist with the same content are left alone. For names
+that are taken by a different entry --strategy decides:
+  rename     add the entry with a -2, -3, ... suffix and update its contexts
+  overwrite  replace the existing entry
+  skip       keep the existing entry
+  ask        ask for every conflict (the default on a terminal)`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var data []byte
+		var err error
+		if args[0] == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", args[0], err)
+		}
+
+		options := utils.ImportOptions{Strategy: importStrategy}
+		if options.Strategy == "" {
+			options.Strategy = utils.ImportRename
+			if isInteractive() {
+				options.Strategy = utils.ImportAsk
+			}
+		}
+		if isInteractive() {
+			options.Ask = askImportStrategy
+		}
+
+		changes, err := kube.Import(cmd.Context(), args[0], data, options)
+		if err != nil {
+			return fmt.Errorf("failed to import kubeconfig: %w", err)
+		}
+
+		for _, change := range changes {
+			entry := fmt.Sprintf("%s '%s'", change.Kind, change.Name)
+			switch change.Action {
+			case "added":
+				fmt.Printf("%s added %s\n", color.GreenString("+"), entry)
+			case "renamed":
+				fmt.Printf("%s added %s as '%s'\n", color.GreenString("+"), entry, color.GreenString(change.NewName))
+			case "overwritten":
+				fmt.Printf("%s overwrote %s\n", color.YellowString("~"), entry)
+			case "skipped":
+				fmt.Printf("%s skipped %s, it already exists\n", color.New(color.Faint).Sprint("-"), entry)
+			default:
+				if change.NewName != "" {
+					entry += fmt.Sprintf(" (as '%s')", change.NewName)
+				}
+				fmt.Printf("%s %s is unchanged\n", color.New(color.Faint).Sprint("="), entry)
+			}
+		}
+		return nil
+	},
+}
+
+func askImportStrategy(kind, name string) (string, error) {
+	prompt := promptui.Select{
+		Label:     fmt.Sprintf("A %s named '%s' already exists", kind, name),
+		Items:     []string{utils.ImportRename, utils.ImportOverwrite, utils.ImportSkip},
+		Templates: selectTemplates,
+	}
+
+	_, strategy, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("import cancelled: %v", err)
+	}
+	return strategy, nil
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importStrategy, "strategy", "", "How to handle names that already exist: rename, overwrite, skip or ask")
+	registerFlagValues(importCmd, "strategy", utils.ImportStrategies...)
+	rootCmd.AddCommand(importCmd)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init <bash|zsh|fish>",
+	Short: "Print the shell integration script",
+	Long: `Print a script that wraps kubec in a shell function and sets up completion.
+kubec runs as a child process and cannot change the environment of your
+shell by itself; through the function it can. This enables:
+
+  - "kubec env <context>" starting a session without eval
+  - sessions for every switch, with "sessions: true" in the kubec config
+  - environment variables per context, set in the kubec config
+
+Add the line for your shell to its startup file:
+
+  bash  eval "$(kubec init bash)"        in ~/.bashrc
+  zsh   eval "$(kubec init zsh)"         in ~/.zshrc
+  fish  kubec init fish | source         in ~/.config/fish/config.fish
+
+The kubec config is $XDG_CONFIG_HOME/kubec/config.yaml, or
+~/.kube/kubec/config.yaml when XDG_CONFIG_HOME is not set:
+
+  sessions: true
+  contexts:
+  - match: "*-prod"
+    env:
+      AWS_PROFILE: production`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: utils.Shells,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := writeShellInit(os.Stdout, args[0]); err != nil {
+			return fmt.Errorf("failed to print shell integration: %w", err)
+		}
+		return nil
+	},
+}
+
+// writeShellInit writes the wrapper function followed by the completion
+// script for shell
+func writeShellInit(w io.Writer, shell string) error {
+	wrapper, err := utils.ShellWrapper(shell)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, wrapper); err != nil {
+		return err
+	}
+
+	switch shell {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(w, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(w)
+	default:
+		return rootCmd.GenFishCompletion(w, true)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteShellInit(t *testing.T) {
+	tests := map[string][]string{
+		"bash": {
+			"kubec() {",
+			`KUBEC_SHELL_HOOK="$hook" KUBEC_SHELL=bash KUBEC_SHELL_PID=$$ command kubec "$@"`,
+			`. "$hook"`,
+			"complete -o default -F __start_kubec kubec",
+		},
+		"zsh": {
+			"kubec() {",
+			`KUBEC_SHELL_HOOK="$hook" KUBEC_SHELL=zsh KUBEC_SHELL_PID=$$ command kubec "$@"`,
+			"#compdef kubec",
+			"compdef _kubec kubec",
+		},
+		"fish": {
+			"function kubec --wraps kubec",
+			"env KUBEC_SHELL_HOOK=$hook KUBEC_SHELL=fish KUBEC_SHELL_PID=$fish_pid kubec $argv",
+			"source $hook",
+			"complete -c kubec",
+		},
+	}
+
+	for shell, expected := range tests {
+		var output bytes.Buffer
+		if err := writeShellInit(&output, shell); err != nil {
+			t.Fatalf("Failed to write %s integration: %v", shell, err)
+		}
+		for _, line := range expected {
+			if !strings.Contains(output.String(), line) {
+				t.Errorf("Expected %s integration to contain %q", shell, line)
+			}
+		}
+	}
+
+	if err := writeShellInit(&bytes.Buffer{}, "tcsh"); err == nil {
+		t.Error("Expected an error for an unsupported shell")
+	}
+}
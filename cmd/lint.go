@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var lintOutput string
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check the kubeconfig for problems",
+	Long: `Check the kubeconfig files for problems: missing or duplicate names,
+references to missing clusters, users or contexts, certificate and key files
+that do not exist, invalid base64 data, invalid or plain http server URLs,
+disabled TLS verification and credential plugins that are not installed.
+
+Every finding has a severity and a file:line:column location. kubec exits
+with status 1 when there is at least one error, warnings alone do not fail.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		findings, err := kube.Lint(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to lint kubeconfig: %w", err)
+		}
+
+		switch lintOutput {
+		case "json":
+			// Always a list, also when there are no findings
+			if findings == nil {
+				findings = []utils.LintFinding{}
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(findings); err != nil {
+				return fmt.Errorf("failed to write findings: %w", err)
+			}
+		case "text":
+			printFindings(findings)
+		default:
+			return usageErrorf("unknown output format '%s', use text or json", lintOutput)
+		}
+
+		for _, finding := range findings {
+			if finding.Severity == utils.SeverityError {
+				return exitStatus(exitFailure)
+			}
+		}
+		return nil
+	},
+}
+
+func printFindings(findings []utils.LintFinding) {
+	if len(findings) == 0 {
+		fmt.Println("No problems found")
+		return
+	}
+
+	errors, warnings := 0, 0
+	for _, finding := range findings {
+		severity := color.YellowString(finding.Severity)
+		if finding.Severity == utils.SeverityError {
+			severity = color.RedString(finding.Severity)
+			errors++
+		} else {
+			warnings++
+		}
+		fmt.Printf("%s: %s: %s %s\n", finding.Location(), severity, finding.Message, color.New(color.Faint).Sprintf("[%s]", finding.Check))
+	}
+	fmt.Printf("\n%d error(s), %d warning(s)\n", errors, warnings)
+}
+
+func init() {
+	lintCmd.Flags().StringVarP(&lintOutput, "output", "o", "text", "Output format: text or json")
+	registerFlagValues(lintCmd, "output", "text", "json")
+	rootCmd.AddCommand(lintCmd)
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats of list and current
+var contextOutputs = []string{"name", "json", "yaml", "wide", "table"}
+
+var listOutput string
+
+// contextListItem is a context as printed by list and current
+type contextListItem struct {
+	utils.ContextSummary `yaml:",inline"`
+	Current              bool `json:"current" yaml:"current"`
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List contexts in a machine-readable format",
+	Long: `List all contexts in alphabetical order. -o name prints one name per line,
+json and yaml print the cluster, server, user, namespace and authentication
+method of every context, table and wide print them as a table (wide also
+shows the certificate authority). The current context is marked.
+
+Colors are left out when stdout is not a terminal or NO_COLOR is set.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := kube.Config(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		items := make([]contextListItem, len(config.Contexts))
+		for i, context := range config.Contexts {
+			items[i] = contextListItem{
+				ContextSummary: config.Describe(context),
+				Current:        context.Name == config.CurrentContext,
+			}
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].Name < items[j].Name
+		})
+
+		// Always a list in json and yaml, also when there are no contexts
+		return writeContexts(os.Stdout, listOutput, items, items)
+	},
+}
+
+// writeContexts prints items in the given format, value is what json and
+// yaml encode
+func writeContexts(w io.Writer, output string, items []contextListItem, value interface{}) error {
+	switch output {
+	case "name":
+		for _, item := range items {
+			fmt.Fprintln(w, item.Name)
+		}
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(value); err != nil {
+			return fmt.Errorf("failed to write contexts: %w", err)
+		}
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(value); err != nil {
+			return fmt.Errorf("failed to write contexts: %w", err)
+		}
+		return encoder.Close()
+	case "table", "wide":
+		printContextTable(w, items, output == "wide")
+	default:
+		return usageErrorf("unknown output format '%s', use %s", output, strings.Join(contextOutputs, ", "))
+	}
+	return nil
+}
+
+func printContextTable(w io.Writer, items []contextListItem, wide bool) {
+	header := []string{"CURRENT", "NAME", "CLUSTER", "SERVER", "USER", "NAMESPACE", "AUTH"}
+	if wide {
+		header = append(header, "CA")
+	}
+
+	rows := [][]string{header}
+	for _, item := range items {
+		current := ""
+		if item.Current {
+			current = "*"
+		}
+		row := []string{current, item.Name, item.Cluster, item.Server, item.User, item.Namespace, item.AuthType}
+		if wide {
+			row = append(row, item.CA)
+		}
+		rows = append(rows, row)
+	}
+
+	for i, cells := range padTable(rows) {
+		line := strings.TrimRight(strings.Join(cells, "   "), " ")
+
+		// Colored after padding, escape codes would break the alignment
+		if i > 0 && items[i-1].Current {
+			line = color.GreenString(line)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// padTable pads the cells of every column to the same width. The last column
+// is not padded, so lines have no trailing spaces.
+func padTable(rows [][]string) [][]string {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if width := utf8.RuneCountInString(cell); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+
+	padded := make([][]string, len(rows))
+	for i, row := range rows {
+		for j, cell := range row {
+			if j < len(row)-1 {
+				cell += strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell))
+			}
+			padded[i] = append(padded[i], cell)
+		}
+	}
+	return padded
+}
+
+func init() {
+	listCmd.Flags().StringVarP(&listOutput, "output", "o", "table", "Output format: name, json, yaml, wide or table")
+	registerFlagValues(listCmd, "output", contextOutputs...)
+	rootCmd.AddCommand(listCmd)
+}
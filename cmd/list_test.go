@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var listTestItems = []contextListItem{
+	{ContextSummary: utils.ContextSummary{Name: "dev", Cluster: "dev-cluster", Server: "https://dev.example.com", User: "dev", AuthType: "token", CA: "inline"}},
+	{ContextSummary: utils.ContextSummary{Name: "prod", Cluster: "prod", Server: "https://prod.example.com", User: "admin", Namespace: "payments", AuthType: "exec (aws)", CA: "system roots"}, Current: true},
+}
+
+func TestWriteContextsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeContexts(&buf, "json", listTestItems, listTestItems); err != nil {
+		t.Fatalf("Failed to write contexts: %v", err)
+	}
+
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not JSON: %v\n%s", err, buf.String())
+	}
+	if len(decoded) != 2 || decoded[1]["name"] != "prod" || decoded[1]["current"] != true || decoded[1]["authType"] != "exec (aws)" {
+		t.Errorf("Unexpected output %v", decoded)
+	}
+}
+
+func TestWriteContextsYAML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeContexts(&buf, "yaml", listTestItems, listTestItems[1]); err != nil {
+		t.Fatalf("Failed to write contexts: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "name: prod\n") || !strings.Contains(buf.String(), "\ncurrent: true\n") {
+		t.Errorf("Expected a flat mapping, but got:\n%s", buf.String())
+	}
+}
+
+func TestWriteContextsTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeContexts(&buf, "table", listTestItems, listTestItems); err != nil {
+		t.Fatalf("Failed to write contexts: %v", err)
+	}
+
+	expected := `CURRENT   NAME   CLUSTER       SERVER                     USER    NAMESPACE   AUTH
+          dev    dev-cluster   https://dev.example.com    dev                 token
+*         prod   prod          https://prod.example.com   admin   payments    exec (aws)
+`
+	// Colors are disabled when stdout is not a terminal, as in tests
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	writeContexts(&buf, "wide", listTestItems, listTestItems)
+	if lines := strings.Split(buf.String(), "\n"); !strings.HasSuffix(lines[0], "   CA") || !strings.HasSuffix(lines[2], "   system roots") {
+		t.Errorf("Expected a CA column, but got:\n%s", buf.String())
+	}
+}
+
+func TestWriteContextsUnknownFormat(t *testing.T) {
+	var usage usageError
+	if err := writeContexts(&bytes.Buffer{}, "xml", listTestItems, listTestItems); !errors.As(err, &usage) {
+		t.Errorf("Expected a usage error, but got %v", err)
+	}
+}
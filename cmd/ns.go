@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var nsCmd = &cobra.Command{
+	Use:   "ns [namespace]",
+	Short: "Switch the namespace of the current context",
+	Long: `Switch the namespace of the current context.
+
+Without an argument the namespaces are listed from the cluster and can be
+selected interactively. When the cluster cannot be reached, the namespaces
+kubec remembers for the context are offered instead.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeNamespaces,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		currentContext, err := kube.CurrentContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if currentContext == "" {
+			fmt.Println("No current context is set")
+			return nil
+		}
+
+		// Direct namespace specification
+		if len(args) > 0 {
+			return switchNamespace(cmd.Context(), currentContext, args[0])
+		}
+
+		// Interactive mode
+		namespaces, err := kube.Namespaces(cmd.Context(), currentContext)
+		if err != nil {
+			var fallback *utils.NamespaceFallbackError
+			if !errors.As(err, &fallback) {
+				return fmt.Errorf("failed to list namespaces: %w", err)
+			}
+			utils.PrintWarning(err.Error())
+		}
+
+		if len(namespaces) == 0 {
+			fmt.Println("No available namespaces found")
+			return nil
+		}
+
+		prompt := promptui.Select{
+			Label:     fmt.Sprintf("Select a namespace for %s", currentContext),
+			Items:     namespaces,
+			Templates: selectTemplates,
+			Size:      selectorSize(len(namespaces)),
+			Searcher: func(input string, index int) bool {
+				_, _, ok := utils.FuzzyMatch(input, namespaces[index])
+				return ok
+			},
+			StartInSearchMode: true,
+		}
+
+		// Set current namespace as initial selection
+		currentNamespace, err := kube.CurrentNamespace(cmd.Context())
+		if err != nil {
+			return err
+		}
+		for i, namespace := range namespaces {
+			if namespace == currentNamespace {
+				prompt.CursorPos = i
+				break
+			}
+		}
+
+		_, selectedNamespace, err := prompt.Run()
+		if err != nil {
+			fmt.Printf("Selection cancelled: %v\n", err)
+			return nil
+		}
+
+		return switchNamespace(cmd.Context(), currentContext, selectedNamespace)
+	},
+}
+
+func switchNamespace(ctx context.Context, contextName, namespace string) error {
+	err := kube.SetNamespace(ctx, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to switch namespace: %w", err)
+	}
+
+	fmt.Printf("Switched to namespace '%s' in context '%s'\n", color.GreenString(namespace), contextName)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(nsCmd)
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package cmd
+
+import "os"
+
+// signalExitStatus cannot tell a signal apart from an exit status here
+func signalExitStatus(state *os.ProcessState) (int, bool) { return 0, false }
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/chzyer/readline"
+	"github.com/manifoldco/promptui"
+)
+
+// isInteractive reports whether kubec can ask the user questions
+func isInteractive() bool {
+	return readline.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// confirm asks a yes/no question, anything but yes is a no
+func confirm(label string) bool {
+	prompt := promptui.Prompt{
+		Label:     label,
+		IsConfirm: true,
+	}
+
+	_, err := prompt.Run()
+	return err == nil
+}
+
+// confirmTyped asks the user on out to type expected, anything else is a no
+func confirmTyped(out io.Writer, label, expected string) bool {
+	prompt := promptui.Prompt{
+		Label:  label,
+		Stdout: nopWriteCloser{out},
+	}
+
+	answer, err := prompt.Run()
+	return err == nil && answer == expected
+}
+
+// nopWriteCloser keeps promptui from closing stdout or stderr
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
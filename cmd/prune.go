@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var (
+	pruneYes    bool
+	pruneDryRun bool
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove clusters and users no context uses",
+	Long: `Check how contexts, clusters and users reference each other and remove
+the clusters and users no context uses.
+
+Contexts that point at missing clusters or users, and names that are defined
+more than once, are reported but left alone. --dry-run shows the changes as a
+diff without writing anything; tokens, keys and other secrets are redacted.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report, err := kube.Analyze(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to analyze kubeconfig: %w", err)
+		}
+
+		for _, dangling := range report.Dangling {
+			utils.PrintWarning(fmt.Sprintf("context '%s' references missing %s '%s'", dangling.Context, dangling.Kind, dangling.Name))
+		}
+		for _, duplicate := range report.Duplicates {
+			utils.PrintWarning(fmt.Sprintf("%s '%s' is defined more than once, the first definition is used: %s",
+				duplicate.Kind, duplicate.Name, strings.Join(duplicate.Files, ", ")))
+		}
+
+		if !report.HasOrphans() {
+			fmt.Println("Nothing to prune")
+			return nil
+		}
+
+		fmt.Println("No context uses these entries:")
+		for _, cluster := range report.OrphanClusters {
+			fmt.Printf("  cluster %s\n", cluster)
+		}
+		for _, user := range report.OrphanUsers {
+			fmt.Printf("  user    %s\n", user)
+		}
+
+		if pruneDryRun {
+			diff, err := kube.PruneDiff(cmd.Context(), report)
+			if err != nil {
+				return fmt.Errorf("failed to compute changes: %w", err)
+			}
+			fmt.Println()
+			printDiff(diff)
+			return nil
+		}
+
+		if !pruneYes && !(isInteractive() && confirm("Remove them")) {
+			fmt.Println("Nothing was removed")
+			return nil
+		}
+
+		err = kube.Prune(cmd.Context(), report)
+		if err != nil {
+			return fmt.Errorf("failed to prune kubeconfig: %w", err)
+		}
+
+		fmt.Printf("Removed %s\n", strings.Join(append(prefixAll("cluster ", report.OrphanClusters), prefixAll("user ", report.OrphanUsers)...), ", "))
+		return nil
+	},
+}
+
+// printDiff prints a unified diff with removed lines in red and added lines
+// in green
+func printDiff(diff string) {
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			fmt.Print(color.New(color.Bold).Sprint(line))
+		case strings.HasPrefix(line, "@@"):
+			fmt.Print(color.CyanString(line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Print(color.RedString(line))
+		case strings.HasPrefix(line, "+"):
+			fmt.Print(color.GreenString(line))
+		default:
+			fmt.Print(line)
+		}
+	}
+}
+
+func init() {
+	pruneCmd.Flags().BoolVarP(&pruneYes, "yes", "y", false, "Remove without asking")
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Show the changes as a diff without writing them")
+	rootCmd.AddCommand(pruneCmd)
+}
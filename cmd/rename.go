@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var renameCmd = &cobra.Command{
+	Use:               "rename <old> <new>",
+	Short:             "Rename a context",
+	Long:              `Rename a context. If it is the current context, current-context is updated as well.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeContexts(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		oldName, newName := args[0], args[1]
+		if strings.TrimSpace(newName) == "" {
+			return usageErrorf("the new context name must not be empty")
+		}
+
+		err := kube.RenameContext(cmd.Context(), oldName, newName)
+		if err != nil {
+			return fmt.Errorf("failed to rename context: %w", err)
+		}
+
+		fmt.Printf("Renamed context '%s' to '%s'\n", oldName, color.GreenString(newName))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRenameRejectsEmptyName(t *testing.T) {
+	writeTestKubeConfig(t, "contexts:\n- name: dev\n")
+
+	for _, name := range []string{"", "  "} {
+		if err := renameCmd.RunE(renameCmd, []string{"dev", name}); exitCode(err) != exitUsage {
+			t.Errorf("Expected a usage error for %q, but got %v", name, err)
+		}
+		if err := copyCmd.RunE(copyCmd, []string{"dev", name}); exitCode(err) != exitUsage {
+			t.Errorf("Expected a usage error for a copy to %q, but got %v", name, err)
+		}
+	}
+	if contexts, _ := kube.Contexts(context.Background()); strings.Join(contexts, ",") != "dev" {
+		t.Errorf("Expected only dev, but got %v", contexts)
+	}
+}
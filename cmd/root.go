@@ -1,111 +1,262 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/fatih/color"
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/kubeconfig"
 	"github.com/ryo-nabata/kubec/utils"
 )
 
-var showCurrent bool
+var (
+	showCurrent bool
+	sortMode    string
+	switchYes   bool
+)
+
+// kube runs the context operations on the kubeconfig files kubectl uses
+var kube = kubeconfig.Default()
+
+// Prompt template shared by the context and namespace selectors
+var selectTemplates = &promptui.SelectTemplates{
+	Label:    "{{ . }}",
+	Active:   "→ {{ . | cyan }}",
+	Inactive: "  {{ . | white }}",
+	Selected: "✓ {{ . | green }}",
+}
 
 var rootCmd = &cobra.Command{
 	Use:   "kubec",
 	Short: "A tool to easily switch Kubernetes contexts",
 	Long:  `kubec is a command-line tool for easily switching Kubernetes current-context.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	// Any argument that is not a subcommand is a context name
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: completeContexts(1),
+	// Errors are printed by Execute, which also picks the exit status
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkSortMode(cmd); err != nil {
+			return err
+		}
+
+		currentContext, err := kube.CurrentContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Show current context
 		if showCurrent {
-			currentContext := utils.GetCurrentContext()
 			if currentContext != "" {
 				fmt.Printf("Current context: %s\n", color.GreenString(currentContext))
 			} else {
 				fmt.Println("No current context is set")
 			}
-			return
+			return nil
+		}
+
+		// Switch back to the previous context, like "cd -"
+		if len(args) > 0 && args[0] == "-" {
+			previousContext := utils.GetPreviousContext(currentContext)
+			if previousContext == "" {
+				fmt.Println("No previous context found in history")
+				return nil
+			}
+			return switchContext(cmd.Context(), previousContext)
 		}
 
 		// Direct context name specification
 		if len(args) > 0 && shouldRunDirectContextSwitch(args[0]) {
-			contextName := args[0]
-			contexts := utils.GetContexts()
-			
-			// Check if the specified context exists
-			found := false
-			for _, c := range contexts {
-				if c == contextName {
-					found = true
-					break
-				}
-			}
-			
-			if !found {
-				fmt.Printf("Context '%s' not found\n", color.RedString(contextName))
-				return
-			}
-			
-			// Switch context
-			err := utils.SetCurrentContext(contextName)
-			if err != nil {
-				log.Fatalf("Failed to switch context: %v", err)
-			}
-			
-			fmt.Printf("Switched to context '%s'\n", color.GreenString(contextName))
-			return
+			return switchToMatchingContext(cmd.Context(), args[0])
 		}
 
 		// Interactive mode
-		contexts := utils.GetContexts()
+		contexts, err := kube.SortedContexts(cmd.Context(), sortMode)
+		if err != nil {
+			return fmt.Errorf("failed to list contexts: %w", err)
+		}
 		if len(contexts) == 0 {
 			fmt.Println("No available contexts found")
-			return
+			return nil
 		}
 
-		currentContext := utils.GetCurrentContext()
-		
-		// Create prompt template
-		templates := &promptui.SelectTemplates{
-			Label:    "{{ . }}",
-			Active:   "→ {{ . | cyan }}",
-			Inactive: "  {{ . | white }}",
-			Selected: "✓ {{ . | green }}",
-		}
+		previousContext := utils.GetPreviousContext(currentContext)
+		items := newContextItems(contexts, currentContext, previousContext, sortMode == utils.SortCluster)
 
-		prompt := promptui.Select{
-			Label:     "Select a context",
-			Items:     contexts,
-			Templates: templates,
+		selectedContext, err := selectContext("Select a context", items)
+		if err != nil {
+			fmt.Printf("Selection cancelled: %v\n", err)
+			return nil
 		}
 
-		// Set current context as initial selection
-		if currentContext != "" {
-			for i, context := range contexts {
-				if context == currentContext {
-					prompt.CursorPos = i
-					break
-				}
+		return switchContext(cmd.Context(), selectedContext)
+	},
+}
+
+// switchToMatchingContext switches to the context meant by query. A unique
+// exact, prefix, substring or fuzzy match is switched to directly; several
+// matches open the selector with only those candidates.
+func switchToMatchingContext(ctx context.Context, query string) error {
+	// Matching by name must not depend on the order of the list
+	contexts, err := kube.SortedContexts(ctx, utils.SortAlphabetical)
+	if err != nil {
+		return fmt.Errorf("failed to list contexts: %w", err)
+	}
+
+	names := make([]string, len(contexts))
+	byName := make(map[string]utils.ContextSummary, len(contexts))
+	for i, context := range contexts {
+		names[i] = context.Name
+		byName[context.Name] = context
+	}
+
+	matches := utils.MatchContexts(query, names)
+	switch len(matches) {
+	case 0:
+		fmt.Printf("Context '%s' not found\n", color.RedString(query))
+		if suggestions := utils.SuggestContexts(query, names, 3); len(suggestions) > 0 {
+			fmt.Println("\nDid you mean this?")
+			for _, suggestion := range suggestions {
+				fmt.Printf("\t%s\n", suggestion)
 			}
 		}
+		return exitStatus(exitContextNotFound)
+	case 1:
+		return switchContext(ctx, matches[0])
+	default:
+		candidates := make([]utils.ContextSummary, len(matches))
+		for i, match := range matches {
+			candidates[i] = byName[match]
+		}
 
-		_, selectedContext, err := prompt.Run()
+		currentContext, err := kube.CurrentContext(ctx)
+		if err != nil {
+			return err
+		}
+		previousContext := utils.GetPreviousContext(currentContext)
+		items := newContextItems(candidates, currentContext, previousContext, sortMode == utils.SortCluster)
+
+		selectedContext, err := selectContext(fmt.Sprintf("Contexts matching '%s'", query), items)
 		if err != nil {
 			fmt.Printf("Selection cancelled: %v\n", err)
-			return
+			return nil
 		}
+		return switchContext(ctx, selectedContext)
+	}
+}
+
+func switchContext(ctx context.Context, contextName string) error {
+	previousContext, err := kube.CurrentContext(ctx)
+	if err != nil {
+		return err
+	}
+	settings, err := utils.LoadSettings()
+	if err != nil {
+		utils.PrintWarning(err.Error())
+	}
+	if err := confirmProtectedSwitch(ctx, os.Stdout, settings, contextName); err != nil {
+		return err
+	}
+	if settings == nil {
+		settings = &utils.Settings{}
+	}
 
-		// Switch context
-		err = utils.SetCurrentContext(selectedContext)
+	if settings.Sessions && utils.ShellHookActive() && utils.CurrentSession() == "" {
+		if err := startSession(ctx, contextName, utils.ShellPID()); err != nil {
+			return err
+		}
+	} else {
+		err = kube.UseContext(ctx, contextName)
 		if err != nil {
-			log.Fatalf("Failed to switch context: %v", err)
+			return fmt.Errorf("failed to switch context: %w", err)
 		}
 
-		fmt.Printf("Switched to context '%s'\n", color.GreenString(selectedContext))
-	},
+		if utils.CurrentSession() != "" {
+			fmt.Printf("Switched to context '%s' in this session\n", color.GreenString(contextName))
+		} else {
+			fmt.Printf("Switched to context '%s'\n", color.GreenString(contextName))
+		}
+	}
+
+	applyContextEnv(settings, previousContext, contextName)
+	return nil
+}
+
+// confirmProtectedSwitch warns about a switch to a protected context and
+// has the user type its name, unless --yes is given. Without settings, when
+// the kubec config cannot be read, every context counts as protected.
+// The banner and the prompt go to out.
+func confirmProtectedSwitch(ctx context.Context, out io.Writer, settings *utils.Settings, contextName string) error {
+	config, err := kube.Config(ctx)
+	if err != nil {
+		return err
+	}
+	if settings != nil && !utils.IsProtected(settings, config, contextName) {
+		return nil
+	}
+
+	utils.FprintDanger(out, fmt.Sprintf(" PROTECTED CONTEXT %s ", contextName))
+	if switchYes {
+		return nil
+	}
+	if !isInteractive() {
+		return fmt.Errorf("context '%s' is protected, use --yes to switch to it without a terminal", contextName)
+	}
+	if !confirmTyped(out, fmt.Sprintf("Type '%s' to switch", contextName), contextName) {
+		return fmt.Errorf("switch to protected context '%s' cancelled", contextName)
+	}
+	return nil
+}
+
+// confirmSessionSwitch runs the protected context check of switchContext
+// before a session for contextName starts
+func confirmSessionSwitch(ctx context.Context, out io.Writer, contextName string) error {
+	settings, err := utils.LoadSettings()
+	if err != nil {
+		utils.FprintWarning(out, err.Error())
+	}
+	return confirmProtectedSwitch(ctx, out, settings, contextName)
+}
+
+// startSession starts a session in the calling shell through the shell
+// integration
+func startSession(ctx context.Context, contextName string, owner int) error {
+	session, err := kube.CreateSession(ctx, contextName, owner)
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	if previous := utils.CurrentSession(); previous != "" {
+		utils.RemoveSession(previous)
+	}
+	if _, err := utils.WriteShellHook(session.Env()); err != nil {
+		utils.RemoveSession(session.Path)
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	fmt.Printf("Switched to context '%s' in this shell\n", color.GreenString(contextName))
+	return nil
+}
+
+// applyContextEnv exports the environment variables configured for the new
+// context into the calling shell
+func applyContextEnv(settings *utils.Settings, previousContext, contextName string) {
+	changes := settings.ContextEnvChanges(previousContext, contextName)
+	if len(changes) == 0 {
+		return
+	}
+
+	applied, err := utils.WriteShellHook(changes)
+	if err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to set environment: %v", err))
+	} else if !applied {
+		utils.PrintInfo(fmt.Sprintf("Environment variables for '%s' need the shell integration, see kubec init --help", contextName))
+	}
 }
 
 func shouldRunDirectContextSwitch(arg string) bool {
@@ -121,13 +272,52 @@ func shouldRunDirectContextSwitch(arg string) bool {
 	return true
 }
 
+func defaultSortMode() string {
+	if mode := os.Getenv("KUBEC_SORT"); mode != "" {
+		return mode
+	}
+	return utils.SortMRU
+}
+
+// checkSortMode rejects an unknown --sort. An unknown KUBEC_SORT is set once
+// for every kubec call, so it only falls back to mru with a warning.
+func checkSortMode(cmd *cobra.Command) error {
+	if utils.IsSortMode(sortMode) {
+		return nil
+	}
+	if cmd.Flags().Changed("sort") {
+		return usageErrorf("unknown sort mode '%s', use %s", sortMode, strings.Join(utils.SortModes, ", "))
+	}
+	utils.FprintWarning(os.Stderr, fmt.Sprintf("Unknown sort mode '%s' in KUBEC_SORT, using %s", sortMode, utils.SortMRU))
+	sortMode = utils.SortMRU
+	return nil
+}
+
 func init() {
 	rootCmd.Flags().BoolVarP(&showCurrent, "current", "c", false, "Show current context")
+	rootCmd.Flags().BoolVarP(&switchYes, "yes", "y", false, "Switch to a protected context without typing its name")
+	rootCmd.Flags().StringVarP(&sortMode, "sort", "s", defaultSortMode(), "Order of the interactive list: mru, alpha or cluster (default from KUBEC_SORT)")
+	registerFlagValues(rootCmd, "sort", utils.SortModes...)
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError{err}
+	})
+	markUsageErrors(rootCmd)
+
+	cmd, err := rootCmd.ExecuteC()
+	if err == nil {
+		return
+	}
+
+	var status exitStatus
+	if !errors.As(err, &status) {
+		fmt.Fprintf(os.Stderr, "%s %v\n", color.RedString("Error:"), err)
+		var usage usageError
+		if errors.As(err, &usage) {
+			fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
+		}
 	}
+	os.Exit(exitCode(err))
 }
\ No newline at end of file
@@ -1,9 +1,40 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/ryo-nabata/kubec/internal/kubetest"
+	"github.com/ryo-nabata/kubec/utils"
 )
 
+// writeTestKubeConfig points KUBECONFIG at a kubeconfig with content and
+// keeps the kubec state and config of the test in temporary directories, so
+// that the settings of the machine running the tests do not apply
+func writeTestKubeConfig(t *testing.T, content string) {
+	t.Helper()
+	path := kubetest.WriteKubeConfig(t, content)
+	t.Setenv("XDG_STATE_HOME", filepath.Dir(path))
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+}
+
+// writeTestSettings writes the kubec config file of the test
+func writeTestSettings(t *testing.T, content string) {
+	t.Helper()
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	if err := os.MkdirAll(filepath.Join(configHome, "kubec"), 0700); err != nil {
+		t.Fatalf("failed to create config directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configHome, "kubec", "config.yaml"), []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+}
+
 func TestShouldRunDirectContextSwitch(t *testing.T) {
 	// Should return true for normal context names
 	normalContexts := []string{
@@ -87,4 +118,124 @@ func TestShouldRunDirectContextSwitchEdgeCases(t *testing.T) {
 			}
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestSwitchToProtectedContext(t *testing.T) {
+	writeTestKubeConfig(t, `apiVersion: v1
+kind: Config
+current-context: dev
+contexts:
+- name: dev
+  context:
+    cluster: c1
+- name: prod
+  context:
+    cluster: c1
+`)
+	writeTestSettings(t, "contexts:\n- match: prod\n  protected: true\n")
+
+	// Tests have no terminal to type the name into
+	err := switchContext(context.Background(), "prod")
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("Expected the switch to need --yes, got %v", err)
+	}
+	if current, _ := kube.CurrentContext(context.Background()); current != "dev" {
+		t.Errorf("Expected to stay on dev, got %s", current)
+	}
+
+	switchYes = true
+	defer func() { switchYes = false }()
+	if err := switchContext(context.Background(), "prod"); err != nil {
+		t.Fatalf("failed to switch with --yes: %v", err)
+	}
+	if current, _ := kube.CurrentContext(context.Background()); current != "prod" {
+		t.Errorf("Expected to switch to prod, got %s", current)
+	}
+}
+
+func TestSwitchWithBrokenSettingsNeedsConfirmation(t *testing.T) {
+	writeTestKubeConfig(t, `apiVersion: v1
+kind: Config
+current-context: dev
+contexts:
+- name: dev
+  context:
+    cluster: c1
+- name: staging
+  context:
+    cluster: c1
+`)
+	writeTestSettings(t, "contexts: [\n")
+
+	// The settings may protect any context, so none is switched to blindly
+	err := switchContext(context.Background(), "staging")
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("Expected the switch to need --yes, got %v", err)
+	}
+	if current, _ := kube.CurrentContext(context.Background()); current != "dev" {
+		t.Errorf("Expected to stay on dev, got %s", current)
+	}
+}
+
+func TestCheckSortMode(t *testing.T) {
+	defer func() {
+		sortMode = defaultSortMode()
+		rootCmd.Flags().Lookup("sort").Changed = false
+	}()
+
+	// A bad KUBEC_SORT must not break every call
+	sortMode = "bogus"
+	if err := checkSortMode(rootCmd); err != nil {
+		t.Errorf("Expected KUBEC_SORT to fall back, got %v", err)
+	}
+	if sortMode != utils.SortMRU {
+		t.Errorf("Expected fallback to %s, got %s", utils.SortMRU, sortMode)
+	}
+
+	if err := rootCmd.Flags().Set("sort", "bogus"); err != nil {
+		t.Fatalf("failed to set --sort: %v", err)
+	}
+	if err := checkSortMode(rootCmd); exitCode(err) != exitUsage {
+		t.Errorf("Expected a usage error for --sort, got %v", err)
+	}
+}
+
+func TestSwitchToMatchingContextIgnoresSortMode(t *testing.T) {
+	writeTestKubeConfig(t, `apiVersion: v1
+kind: Config
+current-context: dev
+contexts:
+- name: dev
+  context:
+    cluster: c1
+- name: staging
+  context:
+    cluster: c1
+`)
+	sortMode = "bogus"
+	defer func() { sortMode = defaultSortMode() }()
+
+	if err := switchToMatchingContext(context.Background(), "stag"); err != nil {
+		t.Fatalf("failed to switch: %v", err)
+	}
+	if current, _ := kube.CurrentContext(context.Background()); current != "staging" {
+		t.Errorf("Expected to switch to staging, got %s", current)
+	}
+	if completions, _ := completeContexts(1)(rootCmd, nil, ""); len(completions) != 2 {
+		t.Errorf("Expected both contexts to complete, got %q", completions)
+	}
+}
+
+func TestProtectedBanner(t *testing.T) {
+	writeTestKubeConfig(t, "contexts:\n- name: prod\n")
+
+	switchYes = true
+	defer func() { switchYes = false }()
+	var out bytes.Buffer
+	if err := confirmProtectedSwitch(context.Background(), &out, nil, "prod"); err != nil {
+		t.Fatalf("failed to confirm switch: %v", err)
+	}
+	if out.String() != "⚠  PROTECTED CONTEXT prod \n" {
+		t.Errorf("Expected only the banner, got %q", out.String())
+	}
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/chzyer/readline"
+	"github.com/manifoldco/promptui"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+// contextItem is one row of the interactive context selector
+type contextItem struct {
+	utils.ContextSummary
+	Current     bool
+	Previous    bool
+	ShowCluster bool
+
+	// Match holds the positions of the name characters matched by the search
+	Match []int
+}
+
+const contextMarks = `{{ if .ShowCluster }} {{ printf "[%s]" .Cluster | faint }}{{ end }}` +
+	`{{ if .Current }} {{ "(current)" | green }}{{ else if .Previous }} {{ "(previous)" | yellow }}{{ end }}`
+
+var contextSelectTemplates = &promptui.SelectTemplates{
+	Label:    "{{ . }}",
+	Active:   `→ {{ highlight .Name .Match "cyan" }}` + contextMarks,
+	Inactive: `  {{ highlight .Name .Match "white" }}` + contextMarks,
+	Selected: "✓ {{ .Name | green }}",
+	Details: `
+{{ "Cluster:  " | faint }}{{ .Cluster }}
+{{ "Server:   " | faint }}{{ .Server }}
+{{ "User:     " | faint }}{{ .User }}
+{{ "Namespace:" | faint }} {{ if .Namespace }}{{ .Namespace }}{{ else }}{{ "default" | faint }}{{ end }}
+{{ "Auth:     " | faint }}{{ .AuthType }}
+{{ "CA:       " | faint }}{{ .CA }}`,
+	FuncMap: selectorFuncMap(),
+}
+
+var highlightStyle = promptui.Styler(promptui.FGYellow, promptui.FGBold, promptui.FGUnderline)
+
+func selectorFuncMap() template.FuncMap {
+	funcs := template.FuncMap{}
+	for name, fn := range promptui.FuncMap {
+		funcs[name] = fn
+	}
+	funcs["highlight"] = highlightMatch
+	return funcs
+}
+
+// highlightMatch renders text in the given promptui color, with the
+// characters at positions emphasized
+func highlightMatch(text string, positions []int, style string) string {
+	base, ok := promptui.FuncMap[style].(func(interface{}) string)
+	if !ok {
+		base = func(v interface{}) string { return v.(string) }
+	}
+	if len(positions) == 0 {
+		return base(text)
+	}
+
+	matched := make(map[int]bool, len(positions))
+	for _, position := range positions {
+		matched[position] = true
+	}
+
+	// Style runs of equally highlighted characters together
+	var result strings.Builder
+	runes := []rune(text)
+	for start := 0; start < len(runes); {
+		end := start
+		for end < len(runes) && matched[end] == matched[start] {
+			end++
+		}
+		segment := string(runes[start:end])
+		if matched[start] {
+			result.WriteString(highlightStyle(segment))
+		} else {
+			result.WriteString(base(segment))
+		}
+		start = end
+	}
+	return result.String()
+}
+
+func newContextItems(contexts []utils.ContextSummary, currentContext, previousContext string, showCluster bool) []*contextItem {
+	items := make([]*contextItem, len(contexts))
+	for i, context := range contexts {
+		items[i] = &contextItem{
+			ContextSummary: context,
+			Current:        context.Name == currentContext,
+			Previous:       context.Name == previousContext,
+			ShowCluster:    showCluster,
+		}
+	}
+	return items
+}
+
+// matchContextItem fuzzy matches the query against the context name, then
+// against the name together with its cluster, user and namespace. Only
+// positions inside the name are returned for highlighting.
+func matchContextItem(query string, item *contextItem) (int, []int, bool) {
+	if score, positions, ok := utils.FuzzyMatch(query, item.Name); ok {
+		return score * 2, positions, true
+	}
+
+	text := strings.Join([]string{item.Name, item.Cluster, item.User, item.Namespace}, " ")
+	score, positions, ok := utils.FuzzyMatch(query, text)
+	if !ok {
+		return 0, nil, false
+	}
+
+	nameLength := len([]rune(item.Name))
+	var namePositions []int
+	for _, position := range positions {
+		if position < nameLength {
+			namePositions = append(namePositions, position)
+		}
+	}
+	return score, namePositions, true
+}
+
+// contextSearch ranks selector items for promptui. promptui can only filter
+// its list, not reorder it, so the list is given slots whose content is
+// rewritten on every new query: the best match goes into the first slot, and
+// the search keeps the first slots that hold matches.
+//
+// When the query is cleared promptui shows every slot again, which keeps the
+// order of the last query until the next search.
+type contextSearch struct {
+	items   []*contextItem
+	slots   []*contextItem
+	query   string
+	matched int
+}
+
+func newContextSearch(items []*contextItem) *contextSearch {
+	search := &contextSearch{items: items, slots: make([]*contextItem, len(items))}
+	for i, item := range items {
+		slot := *item
+		search.slots[i] = &slot
+	}
+	search.matched = len(items)
+	return search
+}
+
+// Searcher is called by promptui for every slot, in order, on each keystroke
+func (s *contextSearch) Searcher(input string, index int) bool {
+	if index == 0 || input != s.query {
+		s.rank(input)
+	}
+	return index < s.matched
+}
+
+func (s *contextSearch) rank(query string) {
+	s.query = query
+
+	type result struct {
+		item      *contextItem
+		score     int
+		positions []int
+	}
+	var matches, rest []result
+	for _, item := range s.items {
+		if score, positions, ok := matchContextItem(query, item); ok {
+			matches = append(matches, result{item, score, positions})
+		} else {
+			rest = append(rest, result{item: item})
+		}
+	}
+
+	// Ties keep the configured order, e.g. most recently used first
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	for i, result := range append(matches, rest...) {
+		*s.slots[i] = *result.item
+		s.slots[i].Match = result.positions
+	}
+	s.matched = len(matches)
+}
+
+// selectorSize returns how many rows fit on the screen next to the search
+// line, label and details, at least promptui's default of 5
+func selectorSize(count int) int {
+	size := 15
+	if _, height, err := readline.GetSize(int(os.Stdout.Fd())); err == nil && height > 0 {
+		size = height - 12
+	}
+	if size > count {
+		size = count
+	}
+	if size < 5 {
+		size = 5
+	}
+	return size
+}
+
+// The selector starts in search mode and its search key leaves it. promptui's
+// default "/" is part of every EKS context name, so Ctrl-X, which readline
+// leaves alone, toggles search instead.
+var contextSelectKeys = &promptui.SelectKeys{
+	Prev:     promptui.Key{Code: promptui.KeyPrev, Display: promptui.KeyPrevDisplay},
+	Next:     promptui.Key{Code: promptui.KeyNext, Display: promptui.KeyNextDisplay},
+	PageUp:   promptui.Key{Code: promptui.KeyBackward, Display: promptui.KeyBackwardDisplay},
+	PageDown: promptui.Key{Code: promptui.KeyForward, Display: promptui.KeyForwardDisplay},
+	Search:   promptui.Key{Code: 'x' & 0x1f, Display: "ctrl-x"},
+}
+
+// selectContext lets the user pick one of items and returns its name. Typing
+// filters the list with fuzzy search.
+func selectContext(label string, items []*contextItem) (string, error) {
+	prompt, search := newContextSelect(label, items)
+
+	index, _, err := prompt.Run()
+	if err != nil {
+		return "", err
+	}
+	return search.slots[index].Name, nil
+}
+
+func newContextSelect(label string, items []*contextItem) (*promptui.Select, *contextSearch) {
+	search := newContextSearch(items)
+
+	prompt := &promptui.Select{
+		Label:             label,
+		Items:             search.slots,
+		Templates:         contextSelectTemplates,
+		Keys:              contextSelectKeys,
+		Size:              selectorSize(len(items)),
+		Searcher:          search.Searcher,
+		StartInSearchMode: true,
+	}
+
+	// Set current context as initial selection
+	for i, item := range items {
+		if item.Current {
+			prompt.CursorPos = i
+			break
+		}
+	}
+	return prompt, search
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/manifoldco/promptui"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+func TestNewContextItemsMarksCurrentAndPrevious(t *testing.T) {
+	contexts := []utils.ContextSummary{
+		{Name: "prod", Cluster: "us"},
+		{Name: "staging", Cluster: "eu"},
+		{Name: "dev", Cluster: "eu"},
+	}
+
+	items := newContextItems(contexts, "staging", "prod", true)
+
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, but got %d", len(items))
+	}
+	if !items[1].Current || items[0].Current || items[2].Current {
+		t.Errorf("Expected only 'staging' to be current, but got %+v", items)
+	}
+	if !items[0].Previous || items[1].Previous || items[2].Previous {
+		t.Errorf("Expected only 'prod' to be previous, but got %+v", items)
+	}
+	if items[0].Cluster != "us" || !items[0].ShowCluster {
+		t.Errorf("Expected cluster 'us' to be shown, but got %+v", items[0])
+	}
+}
+
+func TestContextSelectTemplatesRender(t *testing.T) {
+	item := &contextItem{
+		ContextSummary: utils.ContextSummary{Name: "prod", Cluster: "us", Server: "https://prod", AuthType: "token", CA: "inline"},
+		Current:        true,
+		ShowCluster:    true,
+	}
+
+	for name, text := range map[string]string{
+		"active":   contextSelectTemplates.Active,
+		"inactive": contextSelectTemplates.Inactive,
+		"selected": contextSelectTemplates.Selected,
+		"details":  contextSelectTemplates.Details,
+	} {
+		tpl, err := template.New(name).Funcs(contextSelectTemplates.FuncMap).Parse(text)
+		if err != nil {
+			t.Fatalf("Failed to parse %s template: %v", name, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tpl.Execute(&buf, item); err != nil {
+			t.Fatalf("Failed to render %s template: %v", name, err)
+		}
+		if !strings.Contains(buf.String(), "prod") {
+			t.Errorf("Expected %s template to contain the context name, but got %q", name, buf.String())
+		}
+	}
+}
+
+func TestDefaultSortMode(t *testing.T) {
+	t.Setenv("KUBEC_SORT", "")
+	if defaultSortMode() != utils.SortMRU {
+		t.Errorf("Expected default sort mode %s, but got %s", utils.SortMRU, defaultSortMode())
+	}
+
+	t.Setenv("KUBEC_SORT", utils.SortCluster)
+	if defaultSortMode() != utils.SortCluster {
+		t.Errorf("Expected sort mode from KUBEC_SORT, but got %s", defaultSortMode())
+	}
+}
+
+func TestContextSearchRanksMatches(t *testing.T) {
+	items := []*contextItem{
+		{ContextSummary: utils.ContextSummary{Name: "payments-staging"}},
+		{ContextSummary: utils.ContextSummary{Name: "arn:aws:eks:eu-west-1:123456789012:cluster/payments-prod"}},
+		{ContextSummary: utils.ContextSummary{Name: "prod"}},
+		{ContextSummary: utils.ContextSummary{Name: "dev", Cluster: "prod-cluster"}},
+	}
+	search := newContextSearch(items)
+
+	// promptui calls the searcher for every slot in order
+	var visible []string
+	for i := range search.slots {
+		if search.Searcher("prod", i) {
+			visible = append(visible, search.slots[i].Name)
+		}
+	}
+
+	expected := []string{"prod", "arn:aws:eks:eu-west-1:123456789012:cluster/payments-prod", "dev"}
+	if strings.Join(visible, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected ranked results %v, but got %v", expected, visible)
+	}
+
+	// Matched characters are recorded for highlighting
+	if len(search.slots[0].Match) != 4 {
+		t.Errorf("Expected 4 highlighted characters, but got %v", search.slots[0].Match)
+	}
+	// A match on the cluster highlights nothing in the name
+	if len(search.slots[2].Match) != 0 {
+		t.Errorf("Expected no highlighted characters for a cluster match, but got %v", search.slots[2].Match)
+	}
+
+	// The original items are never modified
+	if items[0].Name != "payments-staging" || items[2].Match != nil {
+		t.Errorf("Expected original items to be untouched, but got %+v", items)
+	}
+}
+
+func TestHighlightMatch(t *testing.T) {
+	plain := highlightMatch("prod", nil, "cyan")
+	if plain != promptui.Styler(promptui.FGCyan)("prod") {
+		t.Errorf("Expected plain cyan text, but got %q", plain)
+	}
+
+	highlighted := highlightMatch("prod", []int{0, 1}, "cyan")
+	expected := highlightStyle("pr") + promptui.Styler(promptui.FGCyan)("od")
+	if highlighted != expected {
+		t.Errorf("Expected %q, but got %q", expected, highlighted)
+	}
+}
+
+func TestSelectorSize(t *testing.T) {
+	if size := selectorSize(2); size != 5 {
+		t.Errorf("Expected minimum size 5, but got %d", size)
+	}
+	if size := selectorSize(1000); size < 5 || size >= 1000 {
+		t.Errorf("Expected size limited by the screen, but got %d", size)
+	}
+}
+
+func TestContextSelectSearchesForSlash(t *testing.T) {
+	items := []*contextItem{
+		{ContextSummary: utils.ContextSummary{Name: "dev"}, Current: true},
+		{ContextSummary: utils.ContextSummary{Name: "arn:aws:eks:eu-west-1:123456789012:cluster/payments-prod"}},
+		{ContextSummary: utils.ContextSummary{Name: "arn:aws:eks:eu-west-1:123456789012:cluster/payments-staging"}},
+	}
+	prompt, search := newContextSelect("Select a context", items)
+	prompt.Stdin = io.NopCloser(strings.NewReader("cluster/payments-s\r"))
+	prompt.Stdout = nopWriteCloser{io.Discard}
+
+	index, _, err := prompt.Run()
+	if err != nil {
+		t.Fatalf("Failed to run selector: %v", err)
+	}
+	if name := search.slots[index].Name; name != items[2].Name {
+		t.Errorf("Expected the query with '/' to select %s, but got %s", items[2].Name, name)
+	}
+}
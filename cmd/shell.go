@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell <context>",
+	Short: "Start a shell with its own current context",
+	Long: `Start your shell ($SHELL) in a kubec session for a context. Switching
+contexts or namespaces inside that shell does not affect any other shell.
+The session file is removed when the shell exits.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContexts(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := confirmSessionSwitch(cmd.Context(), os.Stdout, args[0]); err != nil {
+			return err
+		}
+
+		session, err := kube.CreateSession(cmd.Context(), args[0], os.Getpid())
+		if err != nil {
+			return fmt.Errorf("failed to start session: %w", err)
+		}
+
+		shell := os.Getenv("SHELL")
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+
+		fmt.Printf("Starting a session for context '%s', exit the shell to end it\n", color.GreenString(args[0]))
+
+		child := exec.Command(shell)
+		child.Stdin = os.Stdin
+		child.Stdout = os.Stdout
+		child.Stderr = os.Stderr
+		child.Env = utils.ApplyEnv(os.Environ(), session.Env())
+
+		// Ctrl-C belongs to the shell, kubec waits for it to exit. The signal
+		// is caught rather than ignored: an ignored signal is inherited by
+		// the shell and every command run in it.
+		interrupts := make(chan os.Signal, 1)
+		signal.Notify(interrupts, os.Interrupt)
+		err = child.Run()
+		signal.Stop(interrupts)
+
+		if removeErr := utils.RemoveSession(session.Path); removeErr != nil {
+			utils.PrintWarning(fmt.Sprintf("Failed to remove session %s: %v", session.Dir, removeErr))
+		}
+		fmt.Printf("Session for context '%s' ended\n", args[0])
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// The status of the shell becomes the status of kubec
+			return shellExitStatus(exitErr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to run %s: %w", shell, err)
+		}
+		return nil
+	},
+}
+
+// shellExitStatus is the exit status of the shell, or 128 plus the signal
+// number when a signal ended it
+func shellExitStatus(err *exec.ExitError) exitStatus {
+	if status, ok := signalExitStatus(err.ProcessState); ok {
+		return exitStatus(status)
+	}
+	if code := err.ExitCode(); code >= 0 {
+		return exitStatus(code)
+	}
+	return exitStatus(exitFailure)
+}
+
+func init() {
+	shellCmd.Flags().BoolVarP(&switchYes, "yes", "y", false, "Start a shell for a protected context without typing its name")
+	rootCmd.AddCommand(shellCmd)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestShellKeepsDefaultInterruptHandling(t *testing.T) {
+	if _, err := os.Stat("/proc/self/status"); err != nil {
+		t.Skip("/proc is not available")
+	}
+	writeTestKubeConfig(t, "contexts:\n- name: dev\n")
+	writeTestSettings(t, "")
+	t.Setenv("TMPDIR", t.TempDir())
+
+	// A stand-in shell that records the signals it ignores
+	dir := t.TempDir()
+	output := filepath.Join(dir, "sigign")
+	script := "#!/bin/sh\ngrep SigIgn /proc/$$/status > " + output + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "shell"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write shell: %v", err)
+	}
+	t.Setenv("SHELL", filepath.Join(dir, "shell"))
+
+	shellCmd.SetContext(context.Background())
+	if err := shellCmd.RunE(shellCmd, []string{"dev"}); err != nil {
+		t.Fatalf("failed to run shell: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read shell output: %v", err)
+	}
+
+	// Bit n-1 of the mask stands for signal n, SIGINT is 2
+	mask, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(string(data), "SigIgn:")), 16, 64)
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", data, err)
+	}
+	if mask&(1<<(syscall.SIGINT-1)) != 0 {
+		t.Errorf("Expected the shell to handle SIGINT by default, got SigIgn %x", mask)
+	}
+}
+
+func TestShellExitStatus(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh is not available")
+	}
+	for script, expected := range map[string]int{
+		"exit 3":     3,
+		"kill -9 $$": 128 + 9,
+	} {
+		err := exec.Command("/bin/sh", "-c", script).Run()
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("Expected %q to fail, but got %v", script, err)
+		}
+		if status := shellExitStatus(exitErr); int(status) != expected {
+			t.Errorf("Expected status %d for %q, but got %d", expected, script, status)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chzyer/readline"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+var (
+	statusInterval    time.Duration
+	statusTimeout     time.Duration
+	statusParallelism int
+)
+
+// Credentials expiring within this time are highlighted, as in kubec expiry
+const statusExpiryWarning = 14 * 24 * time.Hour
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the health of every context in a dashboard",
+	Long: `Show one row per context with whether its cluster is ready, the server
+version, when its credentials expire, its namespace and when kubec last
+switched to it. Clusters are checked as kubec check does.
+
+On a terminal the dashboard fills the screen and refreshes every --interval.
+Use the arrow keys or j/k to select a context, Enter to switch to it, r to
+refresh and q to quit. When stdin or stdout is not a terminal, the table is
+printed once.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if statusInterval <= 0 {
+			return usageErrorf("--interval must be positive")
+		}
+		if statusTimeout <= 0 {
+			return usageErrorf("--timeout must be positive")
+		}
+		if statusParallelism < 1 {
+			return usageErrorf("--parallel must be at least 1")
+		}
+
+		if !isInteractive() || !readline.IsTerminal(int(os.Stdout.Fd())) {
+			statuses, err := kube.ContextStatuses(cmd.Context(), statusTimeout, statusParallelism)
+			if err != nil {
+				return err
+			}
+			printStatusTable(os.Stdout, statuses, time.Now())
+			return nil
+		}
+
+		selectedContext, err := runStatusDashboard(cmd.Context())
+		if err != nil || selectedContext == "" {
+			return err
+		}
+		return switchContext(cmd.Context(), selectedContext)
+	},
+}
+
+func printStatusTable(w io.Writer, statuses []utils.ContextStatus, now time.Time) {
+	if len(statuses) == 0 {
+		fmt.Fprintln(w, "No available contexts found")
+		return
+	}
+
+	for _, line := range statusTableLines(statuses, now) {
+		fmt.Fprintln(w, line)
+	}
+
+	// Errors do not fit into the table
+	var errors []string
+	for _, status := range statuses {
+		if status.Check.Error != "" {
+			errors = append(errors, fmt.Sprintf("%s: %s", status.Context, status.Check.Error))
+		}
+	}
+	if len(errors) > 0 {
+		fmt.Fprintln(w)
+		for _, line := range errors {
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// statusTableLines renders the header and one line per status
+func statusTableLines(statuses []utils.ContextStatus, now time.Time) []string {
+	rows := [][]string{{"CURRENT", "CONTEXT", "STATUS", "LATENCY", "VERSION", "NAMESPACE", "EXPIRES", "LAST USED"}}
+	for _, status := range statuses {
+		current := ""
+		if status.Current {
+			current = "*"
+		}
+		namespace := status.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+		latency := "-"
+		if status.Check.ErrorKind != utils.CheckErrorConfig {
+			latency = fmt.Sprintf("%dms", status.Check.LatencyMS)
+		}
+		version := status.Check.Version
+		if version == "" {
+			version = "-"
+		}
+		lastUsed := "-"
+		if !status.LastUsed.IsZero() {
+			lastUsed = formatDuration(now.Sub(status.LastUsed)) + " ago"
+		}
+		expires, _ := describeStatusExpiry(status.Expiry, now)
+
+		rows = append(rows, []string{current, status.Context, describeCheck(status.Check), latency, version, namespace, expires, lastUsed})
+	}
+
+	var lines []string
+	for i, cells := range padTable(rows) {
+		// Colored after padding, escape codes would break the alignment
+		if i > 0 {
+			status := statuses[i-1]
+			if status.Check.Healthy() {
+				cells[2] = color.GreenString(cells[2])
+			} else {
+				cells[2] = color.RedString(cells[2])
+			}
+			if _, colorize := describeStatusExpiry(status.Expiry, now); colorize != nil {
+				cells[6] = colorize(cells[6])
+			}
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, "   "), " "))
+	}
+	return lines
+}
+
+func describeCheck(check utils.ClusterCheck) string {
+	switch {
+	case check.Healthy():
+		return "ready"
+	case check.ErrorKind == utils.CheckErrorConnection:
+		return "unreachable"
+	case check.ErrorKind == utils.CheckErrorTimeout, check.ErrorKind == utils.CheckErrorNotReady:
+		return check.ErrorKind
+	default:
+		return check.ErrorKind + " error"
+	}
+}
+
+// describeStatusExpiry returns when the credential expires and the color it
+// is shown in, nil when it needs no attention
+func describeStatusExpiry(expiry *utils.CredentialExpiry, now time.Time) (string, func(string) string) {
+	red := func(text string) string { return color.RedString(text) }
+	yellow := func(text string) string { return color.YellowString(text) }
+	switch {
+	case expiry == nil:
+		return "-", nil
+	case expiry.Error != "":
+		return "unreadable", red
+	case expiry.Expired(now):
+		return "expired", red
+	case expiry.NotAfter.Before(now.Add(statusExpiryWarning)):
+		return "in " + formatDuration(expiry.NotAfter.Sub(now)), yellow
+	default:
+		return "in " + formatDuration(expiry.NotAfter.Sub(now)), nil
+	}
+}
+
+// Keys understood by the dashboard
+type statusKey int
+
+const (
+	statusKeyUp statusKey = iota
+	statusKeyDown
+	statusKeyEnter
+	statusKeyRefresh
+	statusKeyQuit
+)
+
+// startStatusKeys reads keys from a terminal in raw mode until the returned
+// function is called. That function returns once nothing reads the terminal
+// anymore, so a prompt started afterwards gets all input.
+func startStatusKeys(in *os.File) (<-chan statusKey, func()) {
+	stdin := utils.NewStdinReader(in)
+	keys := make(chan statusKey)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		readStatusKeys(stdin, keys, done)
+	}()
+
+	return keys, func() {
+		close(done)
+		stdin.Close()
+		<-finished
+	}
+}
+
+// readStatusKeys sends the keys read from r until r ends or done is closed
+func readStatusKeys(r io.Reader, keys chan<- statusKey, done <-chan struct{}) {
+	defer close(keys)
+	send := func(key statusKey) bool {
+		select {
+		case keys <- key:
+			return true
+		case <-done:
+			return false
+		}
+	}
+
+	reader := bufio.NewReader(r)
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+		sent := true
+		switch b {
+		case 'k':
+			sent = send(statusKeyUp)
+		case 'j':
+			sent = send(statusKeyDown)
+		case '\r', '\n':
+			sent = send(statusKeyEnter)
+		case 'r':
+			sent = send(statusKeyRefresh)
+		case 'q', 3: // Ctrl-C
+			sent = send(statusKeyQuit)
+		case 0x1b:
+			// Arrow keys are ESC [ A and ESC [ B, or ESC O A in application mode
+			if next, err := reader.ReadByte(); err != nil || (next != '[' && next != 'O') {
+				continue
+			}
+			switch arrow, _ := reader.ReadByte(); arrow {
+			case 'A':
+				sent = send(statusKeyUp)
+			case 'B':
+				sent = send(statusKeyDown)
+			}
+		}
+		if !sent {
+			return
+		}
+	}
+}
+
+// statusDashboard is the state of the full-screen view of kubec status
+type statusDashboard struct {
+	statuses   []utils.ContextStatus
+	cursor     int
+	offset     int
+	updated    time.Time
+	refreshing bool
+	err        error
+}
+
+func (d *statusDashboard) move(delta int) {
+	d.cursor += delta
+	if d.cursor >= len(d.statuses) {
+		d.cursor = len(d.statuses) - 1
+	}
+	if d.cursor < 0 {
+		d.cursor = 0
+	}
+}
+
+// render draws the dashboard for a terminal of the given height
+func (d *statusDashboard) render(w io.Writer, height int, now time.Time) {
+	lines := []string{color.CyanString("kubec status")}
+	switch {
+	case d.updated.IsZero():
+		lines[0] += "  checking clusters..."
+	case d.refreshing:
+		lines[0] += fmt.Sprintf("  updated %s, refreshing...", d.updated.Format("15:04:05"))
+	default:
+		lines[0] += fmt.Sprintf("  updated %s, every %s", d.updated.Format("15:04:05"), statusInterval)
+	}
+	lines = append(lines, color.HiBlackString("↑/↓ select  enter switch  r refresh  q quit"), "")
+
+	if d.err != nil {
+		lines = append(lines, color.RedString(d.err.Error()))
+	}
+
+	if len(d.statuses) > 0 {
+		table := statusTableLines(d.statuses, now)
+		lines = append(lines, "  "+table[0])
+
+		// Keep the cursor in view, leaving room for the details below
+		visible := height - len(lines) - 3
+		if visible < 1 {
+			visible = 1
+		}
+		if d.cursor < d.offset {
+			d.offset = d.cursor
+		}
+		if d.cursor >= d.offset+visible {
+			d.offset = d.cursor - visible + 1
+		}
+
+		for i := d.offset; i < len(d.statuses) && i < d.offset+visible; i++ {
+			prefix := "  "
+			if i == d.cursor {
+				prefix = color.CyanString("→ ")
+			}
+			lines = append(lines, prefix+table[i+1])
+		}
+
+		if selected := d.statuses[d.cursor]; selected.Check.Error != "" {
+			lines = append(lines, "", color.RedString("%s: %s", selected.Context, selected.Check.Error))
+		}
+	} else if !d.updated.IsZero() && d.err == nil {
+		lines = append(lines, "No available contexts found")
+	}
+
+	// Clear the screen and draw from the top left corner
+	fmt.Fprint(w, "\x1b[H\x1b[2J"+strings.Join(lines, "\r\n"))
+}
+
+type statusResult struct {
+	statuses []utils.ContextStatus
+	err      error
+}
+
+// runStatusDashboard shows the dashboard until the user quits or selects a
+// context, which is returned
+func runStatusDashboard(ctx context.Context) (string, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := readline.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	// Use the alternate screen and hide the cursor, like less or top
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		readline.Restore(fd, state)
+	}()
+
+	// Stop reading before a switch may ask for the context name
+	keys, stopKeys := startStatusKeys(os.Stdin)
+	defer stopKeys()
+
+	results := make(chan statusResult, 1)
+	refresh := func() {
+		go func() {
+			statuses, err := kube.ContextStatuses(ctx, statusTimeout, statusParallelism)
+			results <- statusResult{statuses, err}
+		}()
+	}
+
+	dashboard := &statusDashboard{refreshing: true}
+	refresh()
+	ticker := time.NewTicker(statusInterval)
+	defer ticker.Stop()
+
+	for {
+		height := 24
+		if _, h, err := readline.GetSize(int(os.Stdout.Fd())); err == nil && h > 0 {
+			height = h
+		}
+		dashboard.render(os.Stdout, height, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return "", nil
+		case result := <-results:
+			dashboard.refreshing = false
+			dashboard.updated = time.Now()
+			dashboard.err = result.err
+			if result.err == nil {
+				dashboard.statuses = result.statuses
+				dashboard.move(0)
+			}
+		case <-ticker.C:
+			if !dashboard.refreshing {
+				dashboard.refreshing = true
+				refresh()
+			}
+		case key, ok := <-keys:
+			if !ok {
+				return "", nil
+			}
+			switch key {
+			case statusKeyUp:
+				dashboard.move(-1)
+			case statusKeyDown:
+				dashboard.move(1)
+			case statusKeyRefresh:
+				if !dashboard.refreshing {
+					dashboard.refreshing = true
+					refresh()
+				}
+			case statusKeyEnter:
+				if len(dashboard.statuses) > 0 {
+					return dashboard.statuses[dashboard.cursor].Context, nil
+				}
+			case statusKeyQuit:
+				return "", nil
+			}
+		}
+	}
+}
+
+func init() {
+	statusCmd.Flags().DurationVar(&statusInterval, "interval", 10*time.Second, "Time between refreshes of the dashboard")
+	statusCmd.Flags().DurationVar(&statusTimeout, "timeout", 5*time.Second, "Time allowed for each cluster")
+	statusCmd.Flags().IntVarP(&statusParallelism, "parallel", "p", 8, "Number of clusters checked at the same time")
+	rootCmd.AddCommand(statusCmd)
+}
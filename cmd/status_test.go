@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+func TestPrintStatusTable(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	statuses := []utils.ContextStatus{
+		{
+			Context:   "dev",
+			Namespace: "app",
+			Check:     utils.ClusterCheck{Context: "dev", Version: "v1.30.2", Ready: true, LatencyMS: 12},
+			Expiry:    &utils.CredentialExpiry{Context: "dev", NotAfter: now.Add(72 * time.Hour)},
+			LastUsed:  now.Add(-2 * time.Hour),
+		},
+		{
+			Context: "prod",
+			Current: true,
+			Check:   utils.ClusterCheck{Context: "prod", LatencyMS: 3, ErrorKind: utils.CheckErrorTLS, Error: "x509: certificate signed by unknown authority"},
+		},
+	}
+
+	var buf bytes.Buffer
+	printStatusTable(&buf, statuses, now)
+
+	expected := `CURRENT   CONTEXT   STATUS      LATENCY   VERSION   NAMESPACE   EXPIRES   LAST USED
+          dev       ready       12ms      v1.30.2   app         in 3d     2h ago
+*         prod      tls error   3ms       -         default     -         -
+
+prod: x509: certificate signed by unknown authority
+`
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, buf.String())
+	}
+}
+
+func TestReadStatusKeys(t *testing.T) {
+	keys := make(chan statusKey)
+	go readStatusKeys(strings.NewReader("j\x1b[Bk\x1b[A\x1bOBr\rx\x03q"), keys, make(chan struct{}))
+
+	var got []statusKey
+	for key := range keys {
+		got = append(got, key)
+	}
+	expected := []statusKey{statusKeyDown, statusKeyDown, statusKeyUp, statusKeyUp, statusKeyDown, statusKeyRefresh, statusKeyEnter, statusKeyQuit, statusKeyQuit}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, but got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected %v, but got %v", expected, got)
+			break
+		}
+	}
+}
+
+func TestStatusDashboardScrolls(t *testing.T) {
+	dashboard := &statusDashboard{updated: time.Now()}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		dashboard.statuses = append(dashboard.statuses, utils.ContextStatus{Context: name, Check: utils.ClusterCheck{Ready: true}})
+	}
+
+	// Three lines of header, the table header and room for the details leave
+	// three rows on a screen of ten lines
+	dashboard.move(10)
+	var buf bytes.Buffer
+	dashboard.render(&buf, 10, time.Now())
+
+	if dashboard.cursor != 5 || dashboard.offset != 3 {
+		t.Errorf("Expected cursor 5 and offset 3, but got %d and %d", dashboard.cursor, dashboard.offset)
+	}
+	if strings.Contains(buf.String(), "   c   ") || !strings.Contains(buf.String(), "→ ") {
+		t.Errorf("Expected only the last rows with the cursor, but got:\n%s", buf.String())
+	}
+}
+
+func TestStatusKeysReleaseStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	keys, stop := startStatusKeys(r)
+	w.Write([]byte("j"))
+	if key := <-keys; key != statusKeyDown {
+		t.Errorf("Expected down, but got %v", key)
+	}
+	// Keys nobody reads anymore must not keep the reader alive
+	w.Write([]byte("k"))
+	time.Sleep(100 * time.Millisecond)
+	stop()
+
+	// A prompt started after the dashboard gets every key typed into it
+	w.Write([]byte("prod\n"))
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil || line != "prod\n" {
+		t.Errorf("Expected the prompt to read 'prod', but got %q (%v)", line, err)
+	}
+}
+
+func TestStatusRejectsNonPositiveTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { statusTimeout = timeout }(statusTimeout)
+
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		statusTimeout = timeout
+		if err := statusCmd.RunE(statusCmd, nil); exitCode(err) != exitUsage {
+			t.Errorf("Expected a usage error for --timeout %s, but got %v", timeout, err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Package kubetest holds helpers shared by the tests of the other packages.
+// It is only imported from tests, so it never ends up in the kubec binary.
+package kubetest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// WriteKubeConfig writes a kubeconfig file with content to a temporary
+// directory of the test, points KUBECONFIG at it and returns its path
+func WriteKubeConfig(t testing.TB, content string) string {
+	t.Helper()
+	return WriteKubeConfigs(t, content)[0]
+}
+
+// WriteKubeConfigs is WriteKubeConfig for several files, which
+// KUBECONFIG lists in the given order
+func WriteKubeConfigs(t testing.TB, contents ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make([]string, len(contents))
+	for i, content := range contents {
+		paths[i] = filepath.Join(dir, "config")
+		if i > 0 {
+			paths[i] += fmt.Sprint(i + 1)
+		}
+		if err := os.WriteFile(paths[i], []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write test config: %v", err)
+		}
+	}
+	t.Setenv("KUBECONFIG", strings.Join(paths, string(filepath.ListSeparator)))
+	return paths
+}
@@ -0,0 +1,83 @@
+// Package kubeconfig lets Go programs use the context logic of kubec without
+// running the kubec binary: the same merge rules as kubectl for several
+// kubeconfig files, in-place edits that keep comments and unknown fields, and
+// locked writes.
+//
+// Files are kept by a Store. FileStore works on the files kubectl uses,
+// MemoryStore keeps them in memory:
+//
+//	client := kubeconfig.New(kubeconfig.NewFileStore())
+//	if err := client.UseContext(ctx, "prod"); errors.Is(err, kubeconfig.ErrContextNotFound) {
+//		...
+//	}
+package kubeconfig
+
+import (
+	"github.com/ryo-nabata/kubec/utils"
+)
+
+type (
+	// Store keeps kubeconfig files, see utils.Store
+	Store = utils.Store
+
+	// File is one kubeconfig file of a Store
+	File = utils.StoreFile
+
+	FileStore   = utils.FileStore
+	MemoryStore = utils.MemoryStore
+
+	// Client runs context operations on the files of a Store
+	Client = utils.Client
+
+	// Config is the merged content of the kubeconfig files
+	Config = utils.KubeConfig
+
+	ContextNotFoundError = utils.ContextNotFoundError
+	ParseError           = utils.ParseError
+
+	// Results and options of the Client operations
+	ContextSummary   = utils.ContextSummary
+	ContextStatus    = utils.ContextStatus
+	CredentialExpiry = utils.CredentialExpiry
+	LintFinding      = utils.LintFinding
+	PruneReport      = utils.PruneReport
+	ExportOptions    = utils.ExportOptions
+	ImportOptions    = utils.ImportOptions
+	ImportChange     = utils.ImportChange
+	Session          = utils.Session
+)
+
+// Strategies for ImportOptions
+const (
+	ImportRename    = utils.ImportRename
+	ImportOverwrite = utils.ImportOverwrite
+	ImportSkip      = utils.ImportSkip
+	ImportAsk       = utils.ImportAsk
+)
+
+var (
+	ErrKubeconfigNotFound = utils.ErrKubeconfigNotFound
+	ErrContextNotFound    = utils.ErrContextNotFound
+)
+
+// New returns a client for the files of store
+func New(store Store) *Client {
+	return utils.NewClient(store)
+}
+
+// Default returns the client the kubec command uses: the files of KUBECONFIG
+// or ~/.kube/config, with switches recorded in the kubec history
+func Default() *Client {
+	return utils.DefaultClient()
+}
+
+// NewFileStore returns a store for the given files, or for the files of
+// KUBECONFIG or ~/.kube/config when no paths are given
+func NewFileStore(paths ...string) *FileStore {
+	return utils.NewFileStore(paths...)
+}
+
+// NewMemoryStore returns a store holding files in order of precedence
+func NewMemoryStore(files ...File) *MemoryStore {
+	return utils.NewMemoryStore(files...)
+}
@@ -0,0 +1,83 @@
+package kubeconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMemoryStoreClient(t *testing.T) {
+	store := NewMemoryStore(File{Path: "config", Data: []byte(`apiVersion: v1
+kind: Config
+current-context: dev
+contexts:
+- name: dev
+  context:
+    cluster: dev
+- name: prod
+  context:
+    cluster: prod
+`)})
+	client := New(store)
+	ctx := context.Background()
+
+	if err := client.UseContext(ctx, "prod"); err != nil {
+		t.Fatalf("Failed to switch context: %v", err)
+	}
+	if current, _ := client.CurrentContext(ctx); current != "prod" {
+		t.Errorf("Expected current context prod, but got %s", current)
+	}
+
+	var notFound *ContextNotFoundError
+	err := client.UseContext(ctx, "staging")
+	if !errors.Is(err, ErrContextNotFound) || !errors.As(err, &notFound) || notFound.Name != "staging" {
+		t.Errorf("Expected a ContextNotFoundError for staging, but got %v", err)
+	}
+
+	if _, err := New(NewMemoryStore()).Contexts(ctx); !errors.Is(err, ErrKubeconfigNotFound) {
+		t.Errorf("Expected ErrKubeconfigNotFound, but got %v", err)
+	}
+}
+
+func TestMemoryStorePrune(t *testing.T) {
+	store := NewMemoryStore(File{Path: "config", Data: []byte(`apiVersion: v1
+kind: Config
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+- name: old
+  cluster:
+    server: https://old.example.com
+users:
+- name: dev
+  user:
+    token: dev-token
+`)})
+	client := New(store)
+	ctx := context.Background()
+
+	report, err := client.Analyze(ctx)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if len(report.OrphanClusters) != 1 || report.OrphanClusters[0] != "old" {
+		t.Fatalf("Expected cluster old to be orphaned, but got %v", report.OrphanClusters)
+	}
+	if err := client.Prune(ctx, report); err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+
+	config, err := client.Config(ctx)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if len(config.Clusters) != 1 || config.FindCluster("old") != nil {
+		t.Errorf("Expected only cluster dev to remain, but got %+v", config.Clusters)
+	}
+}
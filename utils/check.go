@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of problems found by CheckClusters
+const (
+	CheckErrorConfig     = "config"
+	CheckErrorTLS        = "tls"
+	CheckErrorAuth       = "auth"
+	CheckErrorTimeout    = "timeout"
+	CheckErrorConnection = "connection"
+	CheckErrorNotReady   = "not ready"
+)
+
+// ClusterCheck is the result of contacting the API server of a context
+type ClusterCheck struct {
+	Context   string `json:"context"`
+	Server    string `json:"server"`
+	Version   string `json:"version,omitempty"`
+	Ready     bool   `json:"ready"`
+	LatencyMS int64  `json:"latencyMs"`
+	ErrorKind string `json:"errorKind,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (c ClusterCheck) Healthy() bool {
+	return c.Ready && c.Error == ""
+}
+
+// CheckClusters calls /version and /readyz on the API server of every named
+// context, at most parallelism at a time and each within timeout. The results
+// are in the order of names.
+func CheckClusters(ctx context.Context, config *KubeConfig, names []string, timeout time.Duration, parallelism int) []ClusterCheck {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	checks := make([]ClusterCheck, len(names))
+	slots := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			checks[i] = checkCluster(checkCtx, config, name)
+		}(i, name)
+	}
+	wg.Wait()
+
+	return checks
+}
+
+func checkCluster(ctx context.Context, config *KubeConfig, contextName string) ClusterCheck {
+	check := ClusterCheck{Context: contextName}
+	if kubeContext := config.FindContext(contextName); kubeContext != nil {
+		if cluster := config.FindCluster(kubeContext.Context.Cluster); cluster != nil {
+			check.Server = redactURL(cluster.Cluster.Server)
+		}
+	}
+
+	// Credential plugins run here, so they count against the timeout as well
+	client, err := NewClusterClient(ctx, config, contextName)
+	if err != nil {
+		check.fail(ctx, CheckErrorConfig, err)
+		return check
+	}
+	defer client.Close()
+
+	start := time.Now()
+	body, err := client.Get(ctx, "/version")
+	check.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		check.fail(ctx, classifyCheckError(err), err)
+		return check
+	}
+
+	var version struct {
+		GitVersion string `json:"gitVersion"`
+	}
+	if err := json.Unmarshal(body, &version); err == nil {
+		check.Version = version.GitVersion
+	}
+
+	// /readyz lists the failed checks in its body
+	body, err = client.Get(ctx, "/readyz")
+	if err != nil {
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusInternalServerError {
+			check.fail(ctx, CheckErrorNotReady, errors.New(failedReadyChecks(body)))
+		} else {
+			check.fail(ctx, classifyCheckError(err), err)
+		}
+		return check
+	}
+	check.Ready = strings.TrimSpace(string(body)) == "ok"
+	if !check.Ready {
+		check.ErrorKind = CheckErrorNotReady
+		check.Error = strings.TrimSpace(string(body))
+	}
+	return check
+}
+
+func (c *ClusterCheck) fail(ctx context.Context, kind string, err error) {
+	if ctx.Err() == context.DeadlineExceeded {
+		kind = CheckErrorTimeout
+	}
+	c.ErrorKind = kind
+	c.Error = err.Error()
+}
+
+// classifyCheckError tells TLS and authentication problems apart from
+// clusters that cannot be reached at all
+func classifyCheckError(err error) string {
+	var statusErr *StatusError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certificateErr x509.CertificateInvalidError
+	var verificationErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	switch {
+	case errors.As(err, &statusErr):
+		if statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden {
+			return CheckErrorAuth
+		}
+		return CheckErrorConnection
+	case errors.As(err, &unknownAuthority), errors.As(err, &hostnameErr), errors.As(err, &certificateErr),
+		errors.As(err, &verificationErr), errors.As(err, &recordErr), errors.As(err, &alertErr):
+		return CheckErrorTLS
+	case errors.Is(err, context.DeadlineExceeded):
+		return CheckErrorTimeout
+	default:
+		return CheckErrorConnection
+	}
+}
+
+// failedReadyChecks picks the failed checks from a verbose /readyz response
+func failedReadyChecks(body []byte) string {
+	var failed []string
+	for _, line := range strings.Split(string(body), "\n") {
+		if fields := strings.Fields(strings.TrimPrefix(line, "[-]")); strings.HasPrefix(line, "[-]") && len(fields) > 0 {
+			failed = append(failed, fields[0])
+		}
+	}
+	if len(failed) == 0 {
+		return "readyz check failed"
+	}
+	return "failed checks: " + strings.Join(failed, ", ")
+}
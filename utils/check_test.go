@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newHealthTestServer(t *testing.T, token string, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func healthyHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/version":
+		fmt.Fprint(w, `{"major":"1","minor":"30","gitVersion":"v1.30.2"}`)
+	case "/readyz":
+		fmt.Fprint(w, "ok")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// checkTestConfig has one context per server, named after its index
+func checkTestConfig(servers []*httptest.Server, token string) *KubeConfig {
+	config := &KubeConfig{}
+	for i, server := range servers {
+		single := testServerKubeConfig(server, token)
+		name := fmt.Sprintf("ctx-%d", i)
+		single.Contexts[0].Name = name
+		single.Contexts[0].Context.Cluster = name
+		single.Contexts[0].Context.User = name
+		single.Clusters[0].Name = name
+		single.Users[0].Name = name
+		config.Contexts = append(config.Contexts, single.Contexts...)
+		config.Clusters = append(config.Clusters, single.Clusters...)
+		config.Users = append(config.Users, single.Users...)
+	}
+	return config
+}
+
+func TestCheckClusters(t *testing.T) {
+	healthy := newHealthTestServer(t, "test-token", healthyHandler)
+	unready := newHealthTestServer(t, "test-token", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/readyz" {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "[+]ping ok\n[-]etcd failed: reason withheld\nreadyz check failed\n")
+			return
+		}
+		healthyHandler(w, r)
+	})
+	wrongToken := newHealthTestServer(t, "other-token", healthyHandler)
+	wrongName := newHealthTestServer(t, "test-token", healthyHandler)
+
+	config := checkTestConfig([]*httptest.Server{healthy, unready, wrongToken, wrongName}, "test-token")
+	// The certificate of the test server is not valid for this name
+	config.Clusters[3].Cluster.TLSServerName = "kubernetes.invalid"
+
+	checks := CheckClusters(context.Background(), config, []string{"ctx-0", "ctx-1", "ctx-2", "ctx-3", "missing"}, 5*time.Second, 2)
+	if len(checks) != 5 {
+		t.Fatalf("Expected 5 checks, but got %d", len(checks))
+	}
+
+	if !checks[0].Healthy() || checks[0].Version != "v1.30.2" || checks[0].Server != healthy.URL {
+		t.Errorf("Expected a healthy v1.30.2 cluster, but got %+v", checks[0])
+	}
+
+	expected := []struct {
+		kind  string
+		error string
+	}{
+		{CheckErrorNotReady, "failed checks: etcd"},
+		{CheckErrorAuth, "/version returned 401 Unauthorized"},
+		{CheckErrorTLS, ""},
+		{CheckErrorConfig, "context 'missing' not found"},
+	}
+	for i, want := range expected {
+		check := checks[i+1]
+		if check.Healthy() || check.ErrorKind != want.kind || (want.error != "" && check.Error != want.error) {
+			t.Errorf("Expected %s error %q for %s, but got %+v", want.kind, want.error, check.Context, check)
+		}
+	}
+	if checks[1].Version != "v1.30.2" {
+		t.Errorf("Expected the version of an unready cluster, but got %+v", checks[1])
+	}
+}
+
+func TestCheckClustersTimeoutAndParallelism(t *testing.T) {
+	var running, maxRunning int32
+	slow := newHealthTestServer(t, "test-token", func(w http.ResponseWriter, r *http.Request) {
+		now := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if now <= max || atomic.CompareAndSwapInt32(&maxRunning, max, now) {
+				break
+			}
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	})
+
+	servers := []*httptest.Server{slow, slow, slow, slow}
+	start := time.Now()
+	checks := CheckClusters(context.Background(), checkTestConfig(servers, "test-token"), []string{"ctx-0", "ctx-1", "ctx-2", "ctx-3"}, 100*time.Millisecond, 2)
+
+	for _, check := range checks {
+		if check.ErrorKind != CheckErrorTimeout {
+			t.Errorf("Expected a timeout for %s, but got %+v", check.Context, check)
+		}
+	}
+	if max := atomic.LoadInt32(&maxRunning); max > 2 {
+		t.Errorf("Expected at most 2 checks at a time, but %d ran at once", max)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the checks to give up after the timeout, but they took %v", elapsed)
+	}
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Client runs the context operations of kubec on the kubeconfig files of a
+// Store, with kubectl's merge rules and kubec's in-place edits
+type Client struct {
+	store Store
+
+	// recordState keeps the switch history and remembered namespaces in the
+	// kubec state directory and follows the session of the shell, as the
+	// kubec command does
+	recordState bool
+}
+
+// NewClient returns a client for the files of store. It does not use the
+// kubec history, the namespace cache or the session of the shell.
+func NewClient(store Store) *Client {
+	return &Client{store: store}
+}
+
+// DefaultClient returns a client for the kubeconfig files kubectl uses. It
+// records switches in the kubec history, so "kubec -" can go back to them.
+func DefaultClient() *Client {
+	return &Client{store: NewFileStore(), recordState: true}
+}
+
+// session returns the session file of the shell, or "" for a client that
+// does not follow the environment of the process
+func (c *Client) session() string {
+	if !c.recordState {
+		return ""
+	}
+	return CurrentSession()
+}
+
+func (c *Client) loadFiles(ctx context.Context) ([]*kubeConfigDocument, error) {
+	files, err := loadStoreFiles(ctx, c.store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	return files, nil
+}
+
+func (c *Client) update(ctx context.Context, path string, modify func(doc *kubeConfigDocument) error) error {
+	return updateStoreFile(ctx, c.store, path, modify)
+}
+
+// Config returns the merged kubeconfig
+func (c *Client) Config(ctx context.Context) (*KubeConfig, error) {
+	files, err := c.loadFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return mergeKubeConfigs(files), nil
+}
+
+// Contexts returns the names of all contexts in alphabetical order
+func (c *Client) Contexts(ctx context.Context) ([]string, error) {
+	config, err := c.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var contexts []string
+	for _, context := range config.Contexts {
+		contexts = append(contexts, context.Name)
+	}
+
+	// Sort in alphabetical order
+	sort.Strings(contexts)
+
+	return contexts, nil
+}
+
+func (c *Client) CurrentContext(ctx context.Context) (string, error) {
+	config, err := c.Config(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return config.CurrentContext, nil
+}
+
+func (c *Client) CurrentNamespace(ctx context.Context) (string, error) {
+	config, err := c.Config(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if context := config.FindContext(config.CurrentContext); context != nil {
+		return context.Context.Namespace, nil
+	}
+	return "", nil
+}
+
+// UseContext makes contextName the current context
+func (c *Client) UseContext(ctx context.Context, contextName string) error {
+	files, err := c.loadFiles(ctx)
+	if err != nil {
+		return err
+	}
+	config := mergeKubeConfigs(files)
+
+	if config.FindContext(contextName) == nil {
+		return &ContextNotFoundError{Name: contextName}
+	}
+
+	// Update current-context only in the file that owns it
+	// Only the current-context value is rewritten, the rest of the file is
+	// kept byte for byte
+	target := currentContextFile(files)
+	err = c.update(ctx, target.Path, func(doc *kubeConfigDocument) error {
+		return doc.SetCurrentContext(contextName)
+	})
+	if err != nil {
+		return err
+	}
+
+	// History is best effort, a failure must not fail the switch itself
+	if c.recordState && config.CurrentContext != contextName {
+		recordSwitch(config.CurrentContext, contextName)
+	}
+	return nil
+}
+
+// SetNamespace changes the namespace of the current context in the file that
+// defines the context
+func (c *Client) SetNamespace(ctx context.Context, namespace string) error {
+	files, err := c.loadFiles(ctx)
+	if err != nil {
+		return err
+	}
+	contextName := mergeKubeConfigs(files).CurrentContext
+	if contextName == "" {
+		return fmt.Errorf("no current context is set")
+	}
+
+	var target *kubeConfigDocument
+	for _, file := range files {
+		if file.Config.FindContext(contextName) != nil {
+			target = file
+			break
+		}
+	}
+	if target == nil {
+		return &ContextNotFoundError{Name: contextName}
+	}
+
+	// In a session the namespace is set on a copy of the context in the
+	// session file, which shadows the shared definition
+	if session := c.session(); session != "" && files[0].Path == session && target != files[0] {
+		item := deepCopyNode(target.namedItem("contexts", contextName))
+		stripComments(item)
+		err = c.update(ctx, session, func(doc *kubeConfigDocument) error {
+			if err := doc.AppendItem("contexts", item); err != nil {
+				return err
+			}
+			return doc.SetContextNamespace(contextName, namespace)
+		})
+	} else {
+		err = c.update(ctx, target.Path, func(doc *kubeConfigDocument) error {
+			return doc.SetContextNamespace(contextName, namespace)
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	// Remember the namespace for when the cluster is unreachable
+	if c.recordState {
+		rememberNamespaces(contextName, []string{namespace}, false)
+	}
+	return nil
+}
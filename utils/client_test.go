@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClientWithMemoryStore(t *testing.T) {
+	stateHome := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", stateHome)
+
+	shared := `# shared contexts
+apiVersion: v1
+kind: Config
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    namespace: default
+- name: prod
+  context:
+    cluster: prod
+`
+	store := NewMemoryStore(
+		StoreFile{Path: "local", Data: []byte("current-context: dev\n")},
+		StoreFile{Path: "shared", Data: []byte(shared)},
+	)
+	client := NewClient(store)
+	ctx := context.Background()
+
+	contexts, err := client.Contexts(ctx)
+	if err != nil || strings.Join(contexts, ",") != "dev,prod" {
+		t.Fatalf("Expected dev,prod, but got %v (%v)", contexts, err)
+	}
+
+	if err := client.UseContext(ctx, "prod"); err != nil {
+		t.Fatalf("Failed to switch context: %v", err)
+	}
+	if err := client.SetNamespace(ctx, "payments"); err != nil {
+		t.Fatalf("Failed to switch namespace: %v", err)
+	}
+	if err := client.UseContext(ctx, "staging"); !errors.Is(err, ErrContextNotFound) {
+		t.Errorf("Expected ErrContextNotFound, but got %v", err)
+	}
+
+	files, _ := store.Load(ctx)
+	if string(files[0].Data) != "current-context: prod\n" {
+		t.Errorf("Expected current-context in the first file, but got:\n%s", files[0].Data)
+	}
+	expected := strings.Replace(shared, "    cluster: prod\n", "    namespace: payments\n    cluster: prod\n", 1)
+	if string(files[1].Data) != expected {
+		t.Errorf("Expected only the namespace to be added, but got:\n%s", files[1].Data)
+	}
+	if namespace, _ := client.CurrentNamespace(ctx); namespace != "payments" {
+		t.Errorf("Expected namespace payments, but got %s", namespace)
+	}
+
+	// Only the default client writes kubec state
+	if entries, _ := os.ReadDir(stateHome); len(entries) != 0 {
+		t.Errorf("Expected no state to be written, but found %v", entries)
+	}
+}
+
+func TestClientWithMemoryStoreIgnoresSession(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("TMPDIR", t.TempDir())
+	if err := rememberNamespaces("prod", []string{"cached"}, true); err != nil {
+		t.Fatalf("Failed to remember namespaces: %v", err)
+	}
+
+	// A session of the shell whose file has the same path as a store file
+	sessionPath := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(sessionPath, []byte("current-context: dev\n"), 0600); err != nil {
+		t.Fatalf("Failed to write session file: %v", err)
+	}
+	t.Setenv(SessionEnv, sessionPath)
+
+	store := NewMemoryStore(
+		StoreFile{Path: sessionPath, Data: []byte("current-context: prod\n")},
+		StoreFile{Path: "shared", Data: []byte("contexts:\n- name: prod\n  context:\n    cluster: prod\n")},
+	)
+	client := NewClient(store)
+	ctx := context.Background()
+
+	if err := client.SetNamespace(ctx, "payments"); err != nil {
+		t.Fatalf("Failed to switch namespace: %v", err)
+	}
+	files, _ := store.Load(ctx)
+	if string(files[0].Data) != "current-context: prod\n" || !strings.Contains(string(files[1].Data), "namespace: payments") {
+		t.Errorf("Expected the namespace to be set where the context is defined, but got:\n%s\n%s", files[0].Data, files[1].Data)
+	}
+	if data := readTestFile(t, sessionPath); data != "current-context: dev\n" {
+		t.Errorf("Expected the session file to be untouched, but got:\n%s", data)
+	}
+
+	if namespaces, _, err := client.NamespaceCandidates(ctx); err != nil || strings.Join(namespaces, ",") != "payments" {
+		t.Errorf("Expected only the namespace of the context, but got %v (%v)", namespaces, err)
+	}
+	if _, err := client.CreateSession(ctx, "prod", os.Getpid()); err == nil {
+		t.Error("Expected an error for a session of a memory store, but got none")
+	}
+	if entries, _ := os.ReadDir(os.TempDir()); len(entries) != 0 {
+		t.Errorf("Expected no session to be created, but found %v", entries)
+	}
+}
+
+func TestClientRenameContext(t *testing.T) {
+	store := NewMemoryStore(StoreFile{Path: "config", Data: []byte(manageKubeConfig)})
+	client := NewClient(store)
+
+	if err := client.RenameContext(context.Background(), "dev", "development"); err != nil {
+		t.Fatalf("Failed to rename context: %v", err)
+	}
+	contexts, _ := client.Contexts(context.Background())
+	if strings.Join(contexts, ",") != "development,prod" {
+		t.Errorf("Expected development,prod, but got %v", contexts)
+	}
+}
@@ -0,0 +1,312 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ClusterClient talks to the API server of a kubeconfig context using the
+// credentials from its ClusterInfo and UserInfo.
+type ClusterClient struct {
+	Server   string
+	client   *http.Client
+	token    string
+	username string
+	password string
+}
+
+func NewClusterClient(ctx context.Context, config *KubeConfig, contextName string) (*ClusterClient, error) {
+	kubeContext := config.FindContext(contextName)
+	if kubeContext == nil {
+		return nil, &ContextNotFoundError{Name: contextName}
+	}
+	cluster := config.FindCluster(kubeContext.Context.Cluster)
+	if cluster == nil {
+		return nil, fmt.Errorf("cluster '%s' of context '%s' not found", kubeContext.Context.Cluster, contextName)
+	}
+	if cluster.Cluster.Server == "" {
+		return nil, fmt.Errorf("cluster '%s' has no server", cluster.Name)
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cluster.Cluster.InsecureSkipTLSVerify,
+		ServerName:         cluster.Cluster.TLSServerName,
+	}
+
+	caData, err := readDataOrFile(cluster.Cluster.CertificateAuthorityData, cluster.Cluster.CertificateAuthority)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate authority: %w", err)
+	}
+	if len(caData) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("failed to parse certificate authority of cluster '%s'", cluster.Name)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	client := &ClusterClient{Server: strings.TrimSuffix(cluster.Cluster.Server, "/")}
+
+	if user := config.FindUser(kubeContext.Context.User); user != nil {
+		certData, keyData, err := client.applyCredentials(ctx, user.User)
+		if err != nil {
+			return nil, err
+		}
+		if len(certData) > 0 || len(keyData) > 0 {
+			certificate, err := tls.X509KeyPair(certData, keyData)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load client certificate of user '%s': %w", user.Name, err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{certificate}
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	client.client = &http.Client{Transport: transport}
+
+	return client, nil
+}
+
+// applyCredentials sets the bearer token or basic auth and returns the PEM
+// client certificate and key, if any
+func (c *ClusterClient) applyCredentials(ctx context.Context, user UserInfo) ([]byte, []byte, error) {
+	certData, err := readDataOrFile(user.ClientCertificateData, user.ClientCertificate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read client certificate: %w", err)
+	}
+	keyData, err := readDataOrFile(user.ClientKeyData, user.ClientKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read client key: %w", err)
+	}
+
+	c.token = user.Token
+	if c.token == "" && user.TokenFile != "" {
+		data, err := os.ReadFile(user.TokenFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+		c.token = strings.TrimSpace(string(data))
+	}
+	c.username = user.Username
+	c.password = user.Password
+
+	// OIDC and similar providers keep the last issued token in their config
+	if c.token == "" && user.AuthProvider != nil {
+		if providerConfig, ok := user.AuthProvider["config"].(map[string]interface{}); ok {
+			if idToken, ok := providerConfig["id-token"].(string); ok {
+				c.token = idToken
+			}
+		}
+	}
+
+	if user.Exec != nil {
+		credential, err := runExecPlugin(ctx, user.Exec)
+		if err != nil {
+			return nil, nil, err
+		}
+		if credential.Status.Token != "" {
+			c.token = credential.Status.Token
+		}
+		if credential.Status.ClientCertificateData != "" {
+			certData = []byte(credential.Status.ClientCertificateData)
+			keyData = []byte(credential.Status.ClientKeyData)
+		}
+	}
+
+	return certData, keyData, nil
+}
+
+type execCredential struct {
+	Status struct {
+		Token                 string `json:"token"`
+		ClientCertificateData string `json:"clientCertificateData"`
+		ClientKeyData         string `json:"clientKeyData"`
+	} `json:"status"`
+}
+
+// runExecPlugin runs a client-go credential plugin such as
+// "aws eks get-token" and returns the credential it prints
+func runExecPlugin(ctx context.Context, config *ExecConfig) (*execCredential, error) {
+	cmd := exec.CommandContext(ctx, config.Command, config.Args...)
+	cmd.Env = os.Environ()
+	for _, env := range config.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+
+	apiVersion := config.APIVersion
+	if apiVersion == "" {
+		apiVersion = "client.authentication.k8s.io/v1beta1"
+	}
+	execInfo := fmt.Sprintf(`{"apiVersion":%q,"kind":"ExecCredential","spec":{"interactive":false}}`, apiVersion)
+	cmd.Env = append(cmd.Env, "KUBERNETES_EXEC_INFO="+execInfo)
+
+	// Plugins must not write to the terminal, the status dashboard owns it.
+	// What they report goes into the error instead, on a single line.
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.Join(strings.Fields(stderr.String()), " "); message != "" {
+			return nil, fmt.Errorf("failed to run credential plugin '%s': %w: %s", config.Command, err, message)
+		}
+		return nil, fmt.Errorf("failed to run credential plugin '%s': %w", config.Command, err)
+	}
+
+	var credential execCredential
+	if err := json.Unmarshal(output, &credential); err != nil {
+		return nil, fmt.Errorf("failed to parse output of credential plugin '%s': %w", config.Command, err)
+	}
+	return &credential, nil
+}
+
+// readDataOrFile returns the decoded base64 data field, or the content of the
+// file when only a path is set
+func readDataOrFile(data, path string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	}
+	if path != "" {
+		return os.ReadFile(path)
+	}
+	return nil, nil
+}
+
+// Close closes the idle connections of the client. Every client has its own
+// transport, which would otherwise keep them open until they time out.
+func (c *ClusterClient) Close() {
+	c.client.CloseIdleConnections()
+}
+
+// Get requests path from the API server and returns the response body
+func (c *ClusterClient) Get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Server+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return body, &StatusError{Path: path, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return body, nil
+}
+
+// StatusError is a response of the API server with an unsuccessful status
+type StatusError struct {
+	Path       string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned %s", e.Path, e.Status)
+}
+
+func (c *ClusterClient) ListNamespaces(ctx context.Context) ([]string, error) {
+	body, err := c.Get(ctx, "/api/v1/namespaces")
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse namespace list: %w", err)
+	}
+
+	var namespaces []string
+	for _, item := range list.Items {
+		namespaces = append(namespaces, item.Metadata.Name)
+	}
+	sort.Strings(namespaces)
+
+	return namespaces, nil
+}
+
+var namespaceTimeout = 5 * time.Second
+
+func GetNamespaces(contextName string) ([]string, error) {
+	return DefaultClient().Namespaces(context.Background(), contextName)
+}
+
+// Namespaces lists the namespaces of a context from its cluster and
+// remembers them. When the cluster cannot be reached the remembered list is
+// returned instead, together with the error that caused the fallback.
+func (c *Client) Namespaces(ctx context.Context, contextName string) ([]string, error) {
+	config, err := c.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, namespaceTimeout)
+	defer cancel()
+
+	client, err := NewClusterClient(ctx, config, contextName)
+	if err == nil {
+		defer client.Close()
+		var namespaces []string
+		namespaces, err = client.ListNamespaces(ctx)
+		if err == nil {
+			if c.recordState {
+				rememberNamespaces(contextName, namespaces, true)
+			}
+			return namespaces, nil
+		}
+	}
+
+	var cached []string
+	if c.recordState {
+		cached = GetCachedNamespaces(contextName)
+	}
+	if len(cached) == 0 {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	return cached, &NamespaceFallbackError{Err: err}
+}
+
+// NamespaceFallbackError reports that namespaces came from the local cache
+type NamespaceFallbackError struct {
+	Err error
+}
+
+func (e *NamespaceFallbackError) Error() string {
+	return fmt.Sprintf("cluster unreachable, using remembered namespaces: %v", e.Err)
+}
+
+func (e *NamespaceFallbackError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ryo-nabata/kubec/internal/kubetest"
+)
+
+func newTestAPIServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/api/v1/namespaces" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"kind":"NamespaceList","items":[{"metadata":{"name":"kube-system"}},{"metadata":{"name":"default"}}]}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testServerKubeConfig(server *httptest.Server, token string) KubeConfig {
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return KubeConfig{
+		CurrentContext: "test-context",
+		Contexts: []Context{
+			{Name: "test-context", Context: ContextInfo{Cluster: "test-cluster", User: "test-user"}},
+		},
+		Clusters: []Cluster{
+			{Name: "test-cluster", Cluster: ClusterInfo{
+				Server:                   server.URL,
+				CertificateAuthorityData: base64.StdEncoding.EncodeToString(caData),
+			}},
+		},
+		Users: []User{
+			{Name: "test-user", User: UserInfo{Token: token}},
+		},
+	}
+}
+
+func TestClusterClientListNamespaces(t *testing.T) {
+	server := newTestAPIServer(t, "test-token")
+	config := testServerKubeConfig(server, "test-token")
+
+	client, err := NewClusterClient(context.Background(), &config, "test-context")
+	if err != nil {
+		t.Fatalf("Failed to create cluster client: %v", err)
+	}
+
+	namespaces, err := client.ListNamespaces(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to list namespaces: %v", err)
+	}
+
+	if strings.Join(namespaces, ",") != "default,kube-system" {
+		t.Errorf("Expected [default kube-system], but got %v", namespaces)
+	}
+}
+
+func TestClusterClientRejectsWrongCredentials(t *testing.T) {
+	server := newTestAPIServer(t, "test-token")
+	config := testServerKubeConfig(server, "wrong-token")
+
+	client, err := NewClusterClient(context.Background(), &config, "test-context")
+	if err != nil {
+		t.Fatalf("Failed to create cluster client: %v", err)
+	}
+
+	if _, err := client.ListNamespaces(context.Background()); err == nil {
+		t.Error("Expected error with wrong token, but got none")
+	}
+}
+
+func TestGetNamespacesFallsBackToCache(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	server := newTestAPIServer(t, "test-token")
+
+	kubetest.WriteKubeConfig(t, marshalTestKubeConfig(t, testServerKubeConfig(server, "test-token")))
+
+	// A successful listing is remembered
+	namespaces, err := GetNamespaces("test-context")
+	if err != nil {
+		t.Fatalf("Failed to get namespaces: %v", err)
+	}
+	if len(namespaces) != 2 {
+		t.Errorf("Expected 2 namespaces, but got %v", namespaces)
+	}
+
+	// The remembered list is used once the cluster is gone
+	server.Close()
+	namespaces, err = GetNamespaces("test-context")
+	var fallback *NamespaceFallbackError
+	if !errors.As(err, &fallback) {
+		t.Fatalf("Expected NamespaceFallbackError, but got %v", err)
+	}
+	if strings.Join(namespaces, ",") != "default,kube-system" {
+		t.Errorf("Expected remembered namespaces, but got %v", namespaces)
+	}
+}
+
+func TestGetNamespaceCandidates(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	config := KubeConfig{
+		CurrentContext: "test-context",
+		Contexts: []Context{
+			{Name: "test-context", Context: ContextInfo{Cluster: "test-cluster", Namespace: "payments"}},
+		},
+	}
+
+	kubetest.WriteKubeConfig(t, marshalTestKubeConfig(t, config))
+
+	if err := rememberNamespaces("test-context", []string{"default", "kube-system"}, true); err != nil {
+		t.Fatalf("Failed to remember namespaces: %v", err)
+	}
+	namespaces, current, err := GetNamespaceCandidates()
+	if err != nil {
+		t.Fatalf("Failed to get namespace candidates: %v", err)
+	}
+	if strings.Join(namespaces, ",") != "default,kube-system,payments" || current != "payments" {
+		t.Errorf("Expected remembered namespaces and payments, but got %v and %s", namespaces, current)
+	}
+}
+
+func TestRunExecPluginCapturesStderr(t *testing.T) {
+	config := &ExecConfig{Command: "sh", Args: []string{"-c", "echo 'token expired' >&2; echo 'run login' >&2; exit 1"}}
+
+	_, err := runExecPlugin(context.Background(), config)
+	if err == nil {
+		t.Fatal("Expected error from a failing plugin, but got none")
+	}
+	if !strings.HasSuffix(err.Error(), ": token expired run login") {
+		t.Errorf("Expected the plugin output on one line in the error, but got %q", err)
+	}
+}
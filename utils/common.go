@@ -2,23 +2,16 @@ package utils
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 )
 
-func GetHomeDir() string {
+func GetHomeDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		log.Fatalf("Failed to get home directory: %v", err)
-	}
-	return homeDir
-}
-
-func CheckError(err error) {
-	if err != nil {
-		log.Fatal(err)
+		return "", fmt.Errorf("failed to get home directory: %w", err)
 	}
+	return homeDir, nil
 }
 
 func FileExists(path string) bool {
@@ -30,17 +23,127 @@ func CreateDirectoryIfNotExists(path string) error {
 	if !FileExists(path) {
 		err := os.MkdirAll(path, 0755)
 		if err != nil {
-			return fmt.Errorf("failed to create directory: %v", err)
+			return fmt.Errorf("failed to create directory: %w", err)
 		}
 	}
 	return nil
 }
 
-func GetKubeDirectory() string {
-	return filepath.Join(GetHomeDir(), ".kube")
+// WriteFileAtomic replaces a file through a temporary file in the same
+// directory, so a crash or a full disk never leaves a truncated file behind.
+// Symlinks are resolved and their target is replaced. Existing files keep
+// their owner and mode (masked with maxPerm); new files are created as 0600.
+func WriteFileAtomic(path string, data []byte, maxPerm os.FileMode) error {
+	target, err := resolveSymlinks(path)
+	if err != nil {
+		return err
+	}
+	
+	perm := os.FileMode(0600) & maxPerm
+	info, statErr := os.Stat(target)
+	if statErr == nil {
+		perm = info.Mode().Perm() & maxPerm
+	}
+	
+	dir := filepath.Dir(target)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(target)+".tmp-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+	
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	if statErr == nil {
+		preserveOwner(tmp, info)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	
+	if err := os.Rename(tmpPath, target); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", target, err)
+	}
+	committed = true
+	
+	syncDirectory(dir)
+	return nil
+}
+
+// resolveSymlinks follows symlinks to the real file. Dangling links resolve to
+// the path they point at, so that the link itself is never replaced.
+func resolveSymlinks(path string) (string, error) {
+	for i := 0; i < 40; i++ {
+		info, err := os.Lstat(path)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			return path, nil
+		}
+		
+		link, err := os.Readlink(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve symlink %s: %w", path, err)
+		}
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(path), link)
+		}
+		path = link
+	}
+	return "", fmt.Errorf("too many levels of symlinks: %s", path)
+}
+
+func GetKubeDirectory() (string, error) {
+	homeDir, err := GetHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".kube"), nil
 }
 
 func EnsureKubeDirectory() error {
-	kubeDir := GetKubeDirectory()
+	kubeDir, err := GetKubeDirectory()
+	if err != nil {
+		return err
+	}
 	return CreateDirectoryIfNotExists(kubeDir)
-}
\ No newline at end of file
+}
+
+// GetStateDirectory returns where kubec keeps its own state such as history
+// and remembered namespaces: $XDG_STATE_HOME/kubec if set, ~/.kube/kubec
+// otherwise.
+func GetStateDirectory() (string, error) {
+	if stateHome := os.Getenv("XDG_STATE_HOME"); stateHome != "" {
+		return filepath.Join(stateHome, "kubec"), nil
+	}
+	kubeDir, err := GetKubeDirectory()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(kubeDir, "kubec"), nil
+}
+
+// GetConfigDirectory returns where the kubec configuration file lives:
+// $XDG_CONFIG_HOME/kubec if set, ~/.kube/kubec otherwise.
+func GetConfigDirectory() (string, error) {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return filepath.Join(configHome, "kubec"), nil
+	}
+	kubeDir, err := GetKubeDirectory()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(kubeDir, "kubec"), nil
+}
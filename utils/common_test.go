@@ -6,8 +6,25 @@ import (
 	"testing"
 )
 
+// TestMain keeps kubec state written by the tests (history, remembered
+// namespaces) out of the real home directory
+func TestMain(m *testing.M) {
+	stateDir, err := os.MkdirTemp("", "kubec-state-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_STATE_HOME", stateDir)
+
+	code := m.Run()
+	os.RemoveAll(stateDir)
+	os.Exit(code)
+}
+
 func TestGetHomeDir(t *testing.T) {
-	homeDir := GetHomeDir()
+	homeDir, err := GetHomeDir()
+	if err != nil {
+		t.Fatalf("Failed to get home directory: %v", err)
+	}
 	
 	// Confirm that home directory is not empty
 	if homeDir == "" {
@@ -99,8 +116,12 @@ func TestCreateDirectoryIfNotExistsNestedPath(t *testing.T) {
 }
 
 func TestGetKubeDirectory(t *testing.T) {
-	kubeDir := GetKubeDirectory()
-	expectedDir := filepath.Join(GetHomeDir(), ".kube")
+	kubeDir, err := GetKubeDirectory()
+	if err != nil {
+		t.Fatalf("Failed to get kube directory: %v", err)
+	}
+	homeDir, _ := GetHomeDir()
+	expectedDir := filepath.Join(homeDir, ".kube")
 	
 	if kubeDir != expectedDir {
 		t.Errorf("Expected kube directory %s, but got %s", expectedDir, kubeDir)
@@ -115,7 +136,7 @@ func TestEnsureKubeDirectory(t *testing.T) {
 	os.Setenv("HOME", tempDir)
 	defer os.Setenv("HOME", originalHome)
 	
-	kubeDir := GetKubeDirectory()
+	kubeDir, _ := GetKubeDirectory()
 	
 	// Confirm that it does not exist initially
 	if FileExists(kubeDir) {
@@ -139,4 +160,116 @@ func TestEnsureKubeDirectory(t *testing.T) {
 	if err != nil {
 		t.Errorf("Should not error when kube directory already exists: %v", err)
 	}
-}
\ No newline at end of file
+}
+func TestWriteFileAtomic(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "config")
+
+	// New files are private
+	if err := WriteFileAtomic(path, []byte("first"), 0777); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600 for a new file, but got %o", info.Mode().Perm())
+	}
+
+	// Existing mode is kept
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Fatalf("Failed to chmod file: %v", err)
+	}
+	if err := WriteFileAtomic(path, []byte("second"), 0777); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	info, err = os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("Expected mode 0640 to be kept, but got %o", info.Mode().Perm())
+	}
+
+	// Mode is never wider than maxPerm
+	if err := WriteFileAtomic(path, []byte("third"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	info, err = os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600 after masking, but got %o", info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "third" {
+		t.Errorf("Expected content 'third', but got %q (%v)", data, err)
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the target file in %s, but found %d entries", tempDir, len(entries))
+	}
+}
+
+func TestWriteFileAtomicSymlink(t *testing.T) {
+	tempDir := t.TempDir()
+	dotfiles := filepath.Join(tempDir, "dotfiles")
+	if err := os.Mkdir(dotfiles, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	target := filepath.Join(dotfiles, "kubeconfig")
+	link := filepath.Join(tempDir, "config")
+
+	if err := os.WriteFile(target, []byte("old"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Symlink(filepath.Join("dotfiles", "kubeconfig"), link); err != nil {
+		t.Skipf("Symlinks are not supported: %v", err)
+	}
+
+	if err := WriteFileAtomic(link, []byte("new"), 0777); err != nil {
+		t.Fatalf("Failed to write through symlink: %v", err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatalf("Failed to stat link: %v", err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Expected %s to still be a symlink", link)
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil || string(data) != "new" {
+		t.Errorf("Expected symlink target to contain 'new', but got %q (%v)", data, err)
+	}
+}
+
+func TestGetStateDirectory(t *testing.T) {
+	stateHome := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", stateHome)
+	if stateDir, _ := GetStateDirectory(); stateDir != filepath.Join(stateHome, "kubec") {
+		t.Errorf("Expected state directory under %s, but got %s", stateHome, stateDir)
+	}
+
+	t.Setenv("XDG_STATE_HOME", "")
+	kubeDir, _ := GetKubeDirectory()
+	expected := filepath.Join(kubeDir, "kubec")
+	if stateDir, _ := GetStateDirectory(); stateDir != expected {
+		t.Errorf("Expected state directory %s, but got %s", expected, stateDir)
+	}
+
+	// Without a home directory the error is returned instead of exiting
+	t.Setenv("HOME", "")
+	if _, err := GetStateDirectory(); err == nil {
+		t.Error("Expected error without a home directory, but got none")
+	}
+}
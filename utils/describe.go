@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// ContextSummary describes where a context points and how it authenticates.
+// It never holds secrets, so it is safe to display.
+type ContextSummary struct {
+	Name      string `json:"name" yaml:"name"`
+	Cluster   string `json:"cluster" yaml:"cluster"`
+	Server    string `json:"server" yaml:"server"`
+	User      string `json:"user" yaml:"user"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	AuthType  string `json:"authType" yaml:"authType"`
+	CA        string `json:"ca" yaml:"ca"`
+}
+
+func (c *KubeConfig) Describe(context Context) ContextSummary {
+	summary := ContextSummary{
+		Name:      context.Name,
+		Cluster:   context.Context.Cluster,
+		User:      context.Context.User,
+		Namespace: context.Context.Namespace,
+		AuthType:  "unknown user",
+		CA:        "unknown cluster",
+	}
+
+	if cluster := c.FindCluster(context.Context.Cluster); cluster != nil {
+		summary.Server = redactURL(cluster.Cluster.Server)
+		summary.CA = cluster.Cluster.CASource()
+	}
+	if user := c.FindUser(context.Context.User); user != nil {
+		summary.AuthType = user.User.AuthType()
+	}
+
+	return summary
+}
+
+// AuthType names the authentication methods configured for a user
+func (u UserInfo) AuthType() string {
+	var methods []string
+	if u.Token != "" {
+		methods = append(methods, "token")
+	}
+	if u.TokenFile != "" {
+		methods = append(methods, "token file")
+	}
+	if u.ClientCertificateData != "" {
+		methods = append(methods, "client certificate (inline)")
+	} else if u.ClientCertificate != "" {
+		methods = append(methods, "client certificate (file)")
+	}
+	if u.Username != "" {
+		methods = append(methods, "basic auth")
+	}
+	if u.Exec != nil {
+		// Only the command name, arguments may carry secrets
+		methods = append(methods, "exec ("+filepath.Base(u.Exec.Command)+")")
+	}
+	if u.AuthProvider != nil {
+		name, _ := u.AuthProvider["name"].(string)
+		methods = append(methods, "auth-provider ("+name+")")
+	}
+
+	if len(methods) == 0 {
+		return "none"
+	}
+	return strings.Join(methods, ", ")
+}
+
+// CASource tells where the certificate authority of a cluster comes from
+func (c ClusterInfo) CASource() string {
+	switch {
+	case c.InsecureSkipTLSVerify:
+		return "none (TLS verification disabled)"
+	case c.CertificateAuthorityData != "":
+		return "inline"
+	case c.CertificateAuthority != "":
+		return "file " + c.CertificateAuthority
+	default:
+		return "system roots"
+	}
+}
+
+// redactURL hides a password embedded in a server URL
+func redactURL(server string) string {
+	parsed, err := url.Parse(server)
+	if err != nil || parsed.User == nil {
+		return server
+	}
+	if _, hasPassword := parsed.User.Password(); hasPassword {
+		parsed.User = url.UserPassword(parsed.User.Username(), "xxxxx")
+	}
+	return parsed.String()
+}
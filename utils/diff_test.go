@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	after := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nL\nm\nn\n"
+
+	expected := `--- old
++++ new
+@@ -9,5 +9,6 @@
+ i
+ j
+ k
+-l
++L
+ m
++n
+`
+	if diff := UnifiedDiff("old", "new", []byte(before), []byte(after)); diff != expected {
+		t.Errorf("Expected diff:\n%s\nbut got:\n%s", expected, diff)
+	}
+}
+
+func TestUnifiedDiffSeparateHunks(t *testing.T) {
+	before := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	after := "2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n"
+
+	expected := `--- old
++++ new
+@@ -1,4 +1,3 @@
+-1
+ 2
+ 3
+ 4
+@@ -9,4 +8,3 @@
+ 9
+ 10
+ 11
+-12
+`
+	if diff := UnifiedDiff("old", "new", []byte(before), []byte(after)); diff != expected {
+		t.Errorf("Expected diff:\n%s\nbut got:\n%s", expected, diff)
+	}
+}
+
+func TestUnifiedDiffEqual(t *testing.T) {
+	if diff := UnifiedDiff("old", "new", []byte("same\n"), []byte("same\n")); diff != "" {
+		t.Errorf("Expected no diff for equal content, but got:\n%s", diff)
+	}
+}
+
+func TestDiffLinesEditScript(t *testing.T) {
+	a := []string{"a", "b", "c", "a", "b", "b", "a"}
+	b := []string{"c", "b", "a", "b", "a", "c"}
+
+	ops := diffLines(a, b)
+
+	// Replaying the script must give both inputs back
+	var gotA, gotB []string
+	changes := 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			gotA = append(gotA, op.line)
+		}
+		if op.kind != '-' {
+			gotB = append(gotB, op.line)
+		}
+		if op.kind != ' ' {
+			changes++
+		}
+	}
+	if len(gotA) != len(a) || len(gotB) != len(b) {
+		t.Fatalf("Edit script does not reproduce the inputs: %v", ops)
+	}
+	for i := range a {
+		if gotA[i] != a[i] {
+			t.Fatalf("Edit script does not reproduce the first input: %v", ops)
+		}
+	}
+	for i := range b {
+		if gotB[i] != b[i] {
+			t.Fatalf("Edit script does not reproduce the second input: %v", ops)
+		}
+	}
+
+	// The classic example needs 5 edits
+	if changes != 5 {
+		t.Errorf("Expected 5 changes, but got %d", changes)
+	}
+}
@@ -0,0 +1,779 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kubeConfigDocument keeps the raw bytes of a kubeconfig file next to its
+// parsed model, so that edits can be applied to the original text instead of
+// re-marshaling the KubeConfig struct. Comments, key ordering and fields that
+// the struct does not model survive every edit.
+type kubeConfigDocument struct {
+	Path   string
+	Data   []byte
+	Root   *yaml.Node
+	Config *KubeConfig
+
+	// Checksum is the SHA-256 of the content as it was read from disk
+	Checksum [32]byte
+}
+
+func parseKubeConfigDocument(path string, data []byte) (*kubeConfigDocument, error) {
+	doc := &kubeConfigDocument{Path: path}
+	if err := doc.update(data); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// update replaces the document content and re-parses it
+func (d *kubeConfigDocument) update(data []byte) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return newParseError(d.Path, err)
+	}
+
+	var config KubeConfig
+	if err := root.Decode(&config); err != nil && root.Kind != 0 {
+		return newParseError(d.Path, err)
+	}
+
+	resolveLocalPaths(&config, filepath.Dir(d.Path))
+
+	d.Data = data
+	d.Root = &root
+	d.Config = &config
+	return nil
+}
+
+// mapping returns the top-level mapping node, or nil for an empty document
+func (d *kubeConfigDocument) mapping() *yaml.Node {
+	if d.Root.Kind != yaml.DocumentNode || len(d.Root.Content) == 0 {
+		return nil
+	}
+	if d.Root.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	return d.Root.Content[0]
+}
+
+// reencode serializes the node tree. It is only used when an edit cannot be
+// applied to the original text; comments and unknown fields are still kept.
+func (d *kubeConfigDocument) reencode() error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(d.Root); err != nil {
+		return fmt.Errorf("failed to prepare kubeconfig write: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to prepare kubeconfig write: %w", err)
+	}
+	return d.update(buf.Bytes())
+}
+
+func (d *kubeConfigDocument) SetCurrentContext(contextName string) error {
+	return d.setMappingValue(d.ensureMapping(), "current-context", contextName)
+}
+
+// ensureMapping returns the top-level mapping, starting a new document when
+// the file is empty
+func (d *kubeConfigDocument) ensureMapping() *yaml.Node {
+	if mapping := d.mapping(); mapping != nil {
+		return mapping
+	}
+	d.Root = &yaml.Node{
+		Kind:    yaml.DocumentNode,
+		Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+	}
+	return d.Root.Content[0]
+}
+
+func (d *kubeConfigDocument) SetContextNamespace(contextName, namespace string) error {
+	item := d.namedItem("contexts", contextName)
+	if item == nil {
+		return fmt.Errorf("context '%s' not found in %s", contextName, d.Path)
+	}
+
+	_, info := mappingValue(item, "context")
+	if info == nil || info.Kind != yaml.MappingNode {
+		return fmt.Errorf("context '%s' in %s has no context section", contextName, d.Path)
+	}
+	return d.setMappingValue(info, "namespace", namespace)
+}
+
+// namedItem returns the entry called name from a top-level list such as
+// "contexts", "clusters" or "users"
+func (d *kubeConfigDocument) namedItem(listKey, name string) *yaml.Node {
+	_, list := mappingValue(d.mapping(), listKey)
+	index := namedItemIndex(list, name)
+	if index < 0 {
+		return nil
+	}
+	return list.Content[index]
+}
+
+func namedItemIndex(list *yaml.Node, name string) int {
+	if list == nil || list.Kind != yaml.SequenceNode {
+		return -1
+	}
+	for i, item := range list.Content {
+		if _, nameNode := mappingValue(item, "name"); nameNode != nil && nameNode.Value == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (d *kubeConfigDocument) RenameItem(listKey, oldName, newName string) error {
+	item := d.namedItem(listKey, oldName)
+	if item == nil {
+		return fmt.Errorf("%s '%s' not found in %s", strings.TrimSuffix(listKey, "s"), oldName, d.Path)
+	}
+	return d.setMappingValue(item, "name", newName)
+}
+
+// RemoveItem deletes the entry called name from a top-level list. The lines
+// of the entry and the comment above it are cut out of the file, everything
+// else is left as it was.
+func (d *kubeConfigDocument) RemoveItem(listKey, name string) error {
+	_, list := mappingValue(d.mapping(), listKey)
+	index := namedItemIndex(list, name)
+	if index < 0 {
+		return fmt.Errorf("%s '%s' not found in %s", strings.TrimSuffix(listKey, "s"), name, d.Path)
+	}
+
+	if start, end, ok := d.sequenceItemSpan(listKey, index); ok {
+		// Comment lines directly above the entry describe it and go with it
+		start = commentLinesAbove(d.Data, start)
+		return d.update(splice(d.Data, start, end, ""))
+	}
+
+	list.Content = append(list.Content[:index], list.Content[index+1:]...)
+	return d.reencode()
+}
+
+// RemoveItems deletes every entry of a list whose name is in names
+func (d *kubeConfigDocument) RemoveItems(listKey string, names []string) error {
+	for _, name := range names {
+		for d.namedItem(listKey, name) != nil {
+			if err := d.RemoveItem(listKey, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AppendItem adds an entry to the end of a top-level list, creating the list
+// when it does not exist yet. The entry is inserted as text after the last
+// entry, with the same indentation.
+func (d *kubeConfigDocument) AppendItem(listKey string, item *yaml.Node) error {
+	mapping := d.ensureMapping()
+	_, list := mappingValue(mapping, listKey)
+
+	if list != nil && list.Kind == yaml.SequenceNode && len(list.Content) > 0 {
+		last := len(list.Content) - 1
+		_, end, ok := d.sequenceItemSpan(listKey, last)
+		indent, indentOK := d.sequenceIndent(list.Content[last])
+		if text, renderOK := renderSequenceItem(item, indent); ok && indentOK && renderOK {
+			if end > 0 && d.Data[end-1] != '\n' {
+				text = "\n" + text
+			}
+			return d.update(splice(d.Data, end, end, text))
+		}
+	}
+
+	if list == nil && mapping.Style&yaml.FlowStyle == 0 {
+		if text, ok := renderSequenceItem(item, ""); ok {
+			text = listKey + ":\n" + text
+			if len(d.Data) > 0 && d.Data[len(d.Data)-1] != '\n' {
+				text = "\n" + text
+			}
+			return d.update(splice(d.Data, len(d.Data), len(d.Data), text))
+		}
+	}
+
+	// Flow style or empty lists such as "users: []" are rewritten as a whole
+	switch {
+	case list == nil:
+		list = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: listKey}, list)
+	case list.Kind != yaml.SequenceNode:
+		*list = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+	list.Style = 0
+	list.Content = append(list.Content, item)
+	return d.reencode()
+}
+
+// ReplaceItem replaces the entry called name in a top-level list
+func (d *kubeConfigDocument) ReplaceItem(listKey, name string, item *yaml.Node) error {
+	_, list := mappingValue(d.mapping(), listKey)
+	index := namedItemIndex(list, name)
+	if index < 0 {
+		return fmt.Errorf("%s '%s' not found in %s", strings.TrimSuffix(listKey, "s"), name, d.Path)
+	}
+
+	if start, end, ok := d.sequenceItemSpan(listKey, index); ok {
+		if indent, ok := d.sequenceIndent(list.Content[index]); ok {
+			if text, ok := renderSequenceItem(item, indent); ok {
+				return d.update(splice(d.Data, start, end, text))
+			}
+		}
+	}
+
+	list.Content[index] = item
+	return d.reencode()
+}
+
+// sequenceIndent returns the spaces before the "-" of a block list entry
+func (d *kubeConfigDocument) sequenceIndent(item *yaml.Node) (string, bool) {
+	lineStart, ok := nodeOffset(d.Data, &yaml.Node{Line: item.Line, Column: 1})
+	if !ok {
+		return "", false
+	}
+	itemStart, ok := nodeOffset(d.Data, item)
+	if !ok {
+		return "", false
+	}
+	prefix := string(d.Data[lineStart:itemStart])
+	dash := strings.IndexByte(prefix, '-')
+	if dash < 0 || strings.Trim(prefix[:dash], " ") != "" {
+		return "", false
+	}
+	return prefix[:dash], true
+}
+
+// renderSequenceItem formats item as a block list entry with every line
+// indented by indent
+func renderSequenceItem(item *yaml.Node, indent string) (string, bool) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{item}}); err != nil {
+		return "", false
+	}
+	if err := encoder.Close(); err != nil {
+		return "", false
+	}
+
+	lines := strings.SplitAfter(buf.String(), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, ""), true
+}
+
+// CopyContext adds a copy of a context under a new name, right after the
+// original, optionally with another namespace
+func (d *kubeConfigDocument) CopyContext(source, destination, namespace string) error {
+	_, list := mappingValue(d.mapping(), "contexts")
+	index := namedItemIndex(list, source)
+	if index < 0 {
+		return fmt.Errorf("context '%s' not found in %s", source, d.Path)
+	}
+
+	if start, end, ok := d.sequenceItemSpan("contexts", index); ok {
+		text := string(d.Data[start:end])
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		if err := d.update(splice(d.Data, end, end, text)); err != nil {
+			return err
+		}
+	} else {
+		copied := deepCopyNode(list.Content[index])
+		list.Content = append(list.Content[:index+1], append([]*yaml.Node{copied}, list.Content[index+1:]...)...)
+		if err := d.reencode(); err != nil {
+			return err
+		}
+	}
+
+	// The copy is the entry following the original
+	_, list = mappingValue(d.mapping(), "contexts")
+	copied := list.Content[index+1]
+	if err := d.setMappingValue(copied, "name", destination); err != nil {
+		return err
+	}
+	if namespace != "" {
+		return d.SetContextNamespace(destination, namespace)
+	}
+	return nil
+}
+
+func deepCopyNode(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = deepCopyNode(child)
+	}
+	return &copied
+}
+
+// secretKeys are the user fields whose values are credentials. Environment
+// variables of exec plugins are treated as secrets as well.
+var secretKeys = map[string]bool{
+	"token":           true,
+	"password":        true,
+	"client-key-data": true,
+	"id-token":        true,
+	"refresh-token":   true,
+	"access-token":    true,
+	"client-secret":   true,
+}
+
+const redactedValue = "REDACTED"
+
+// redactedData returns the content with the secret values of users replaced
+// by REDACTED, so that it can be displayed. Values are replaced in the
+// original text where possible, which keeps line numbers unchanged.
+func (d *kubeConfigDocument) redactedData() ([]byte, error) {
+	values := secretValues(d.mapping())
+	if len(values) == 0 {
+		return d.Data, nil
+	}
+
+	// Later values first, so that earlier offsets stay valid
+	sort.Slice(values, func(i, j int) bool {
+		if values[i][1].Line != values[j][1].Line {
+			return values[i][1].Line > values[j][1].Line
+		}
+		return values[i][1].Column > values[j][1].Column
+	})
+	redacted := &kubeConfigDocument{Path: d.Path, Data: d.Data}
+	for _, value := range values {
+		// Plain values in flow mappings end before the next ',' or '}',
+		// which spliceScalar does not know about
+		if start, ok := nodeOffset(redacted.Data, value[1]); ok && value[1].Style == 0 &&
+			bytes.HasPrefix(redacted.Data[start:], []byte(value[1].Value)) {
+			redacted.Data = splice(redacted.Data, start, start+len(value[1].Value), redactedValue)
+			continue
+		}
+		data, ok := redacted.spliceScalar(value[0], value[1], redactedValue)
+		if !ok {
+			return d.reencodeRedacted()
+		}
+		redacted.Data = data
+	}
+	return redacted.Data, nil
+}
+
+// reencodeRedacted is the fallback of redactedData for values that cannot be
+// replaced in the text, such as multi-line scalars
+func (d *kubeConfigDocument) reencodeRedacted() ([]byte, error) {
+	root := deepCopyNode(d.Root)
+	copied := &kubeConfigDocument{Path: d.Path, Root: root}
+	for _, value := range secretValues(copied.mapping()) {
+		*value[1] = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: redactedValue, Line: value[1].Line, Column: value[1].Column}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return nil, fmt.Errorf("failed to redact kubeconfig: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to redact kubeconfig: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// secretValues returns the key and value nodes of the non-empty secrets in
+// the users of a top-level mapping
+func secretValues(mapping *yaml.Node) [][2]*yaml.Node {
+	_, users := mappingValue(mapping, "users")
+	if users == nil || users.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	var values [][2]*yaml.Node
+	var walk func(node *yaml.Node, parent string)
+	walk = func(node *yaml.Node, parent string) {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				secret := secretKeys[key.Value] || (parent == "env" && key.Value == "value")
+				if secret && value.Kind == yaml.ScalarNode && value.Value != "" {
+					values = append(values, [2]*yaml.Node{key, value})
+					continue
+				}
+				walk(value, key.Value)
+			}
+		case yaml.SequenceNode:
+			for _, child := range node.Content {
+				walk(child, parent)
+			}
+		}
+	}
+	for _, user := range users.Content {
+		_, info := mappingValue(user, "user")
+		if info != nil {
+			walk(info, "user")
+		}
+	}
+	return values
+}
+
+// sequenceItemSpan returns the byte range of the lines that make up an entry
+// of a block-style top-level list. Comment and blank lines just before the
+// next entry or key, or at the end of the file, do not belong to the entry and
+// are not included.
+func (d *kubeConfigDocument) sequenceItemSpan(listKey string, index int) (int, int, bool) {
+	mapping := d.mapping()
+	_, list := mappingValue(mapping, listKey)
+	if list == nil || list.Style&yaml.FlowStyle != 0 || mapping.Style&yaml.FlowStyle != 0 {
+		return 0, 0, false
+	}
+
+	item := list.Content[index]
+	start, ok := nodeOffset(d.Data, &yaml.Node{Line: item.Line, Column: 1})
+	if !ok {
+		return 0, 0, false
+	}
+	itemStart, ok := nodeOffset(d.Data, item)
+	if !ok || strings.TrimSpace(string(d.Data[start:itemStart])) != "-" {
+		return 0, 0, false
+	}
+
+	// The entry ends where the next entry, or the next top-level key, starts
+	end := len(d.Data)
+	nextLine := 0
+	if index+1 < len(list.Content) {
+		nextLine = list.Content[index+1].Line
+	} else {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i+1] == list && i+2 < len(mapping.Content) {
+				nextLine = mapping.Content[i+2].Line
+			}
+		}
+	}
+	if nextLine > 0 {
+		if end, ok = nodeOffset(d.Data, &yaml.Node{Line: nextLine, Column: 1}); !ok {
+			return 0, 0, false
+		}
+	}
+
+	return start, skipBackOverComments(d.Data, start, end), true
+}
+
+// skipBackOverComments moves end back over whole comment and blank lines
+func skipBackOverComments(data []byte, start, end int) int {
+	for end > start {
+		lineStart := bytes.LastIndexByte(data[:end-1], '\n') + 1
+		if lineStart < start {
+			break
+		}
+		line := strings.TrimSpace(string(data[lineStart:end]))
+		if line != "" && !strings.HasPrefix(line, "#") {
+			break
+		}
+		end = lineStart
+	}
+	return end
+}
+
+// commentLinesAbove moves start back over the comment lines right above it.
+// A blank line ends the comment.
+func commentLinesAbove(data []byte, start int) int {
+	for start > 0 {
+		lineStart := bytes.LastIndexByte(data[:start-1], '\n') + 1
+		if !strings.HasPrefix(strings.TrimSpace(string(data[lineStart:start])), "#") {
+			break
+		}
+		start = lineStart
+	}
+	return start
+}
+
+// setMappingValue sets a scalar value in a mapping node. Only the bytes of the
+// value are replaced when the key already exists, and a single line is
+// inserted when it does not.
+func (d *kubeConfigDocument) setMappingValue(mapping *yaml.Node, key, value string) error {
+	keyNode, valueNode := mappingValue(mapping, key)
+
+	if mapping.Style&yaml.FlowStyle != 0 {
+		if data, ok := d.spliceFlowValue(mapping, valueNode, key, value); ok {
+			return d.update(data)
+		}
+	}
+
+	if keyNode != nil {
+		if data, ok := d.spliceScalar(keyNode, valueNode, value); ok {
+			return d.update(data)
+		}
+
+		// Fall back to editing the node tree
+		*valueNode = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+		return d.reencode()
+	}
+
+	if data, ok := d.insertMappingLine(mapping, key, value); ok {
+		return d.update(data)
+	}
+
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	)
+	return d.reencode()
+}
+
+func mappingValue(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+func (d *kubeConfigDocument) spliceScalar(keyNode, valueNode *yaml.Node, value string) ([]byte, bool) {
+	if valueNode.Kind != yaml.ScalarNode {
+		return nil, false
+	}
+
+	// An empty value such as "current-context:" has no text of its own,
+	// the new value goes right after the colon
+	if valueNode.Value == "" && valueNode.Style == 0 {
+		keyStart, ok := nodeOffset(d.Data, keyNode)
+		if !ok {
+			return nil, false
+		}
+		colon := keyStart + len(keyNode.Value)
+		if keyNode.Style != 0 || colon >= len(d.Data) || d.Data[colon] != ':' {
+			return nil, false
+		}
+		return splice(d.Data, colon+1, colon+1, " "+renderScalar(value, 0)), true
+	}
+
+	start, ok := nodeOffset(d.Data, valueNode)
+	if !ok {
+		return nil, false
+	}
+	end, ok := scalarEnd(d.Data, start, valueNode.Style)
+	if !ok {
+		return nil, false
+	}
+
+	// Make sure the located text really is the value before replacing it
+	var current string
+	if err := yaml.Unmarshal(d.Data[start:end], &current); err != nil || current != valueNode.Value {
+		return nil, false
+	}
+
+	return splice(d.Data, start, end, renderScalar(value, valueNode.Style)), true
+}
+
+// spliceFlowValue sets key in a flow mapping such as "{cluster: b, user: u}"
+// by editing its text: an existing value is replaced, a new key is added
+// before the closing brace. Nested collections are not handled.
+func (d *kubeConfigDocument) spliceFlowValue(mapping, valueNode *yaml.Node, key, value string) ([]byte, bool) {
+	// The quoting of a replaced value is kept, and flow indicators in a
+	// plain value would end it early
+	var style yaml.Style
+	if valueNode != nil {
+		style = valueNode.Style
+	}
+	rendered := renderScalar(value, style)
+	if strings.ContainsAny(rendered, ",[]{}") && style == 0 {
+		rendered = renderScalar(value, yaml.DoubleQuotedStyle)
+	}
+
+	if valueNode != nil {
+		start, ok := nodeOffset(d.Data, valueNode)
+		if !ok {
+			return nil, false
+		}
+		end, ok := flowScalarEnd(d.Data, start, valueNode)
+		if !ok {
+			return nil, false
+		}
+		return splice(d.Data, start, end, rendered), true
+	}
+
+	// The new key goes after the last value, or right after "{"
+	entry := key + ": " + rendered
+	insertAt, ok := nodeOffset(d.Data, mapping)
+	if !ok || insertAt >= len(d.Data) || d.Data[insertAt] != '{' {
+		return nil, false
+	}
+	insertAt++
+	if len(mapping.Content) > 0 {
+		last := mapping.Content[len(mapping.Content)-1]
+		start, ok := nodeOffset(d.Data, last)
+		if !ok {
+			return nil, false
+		}
+		if insertAt, ok = flowScalarEnd(d.Data, start, last); !ok {
+			return nil, false
+		}
+		entry = ", " + entry
+	}
+
+	// Only spaces may separate the insertion point from the closing brace
+	rest := bytes.TrimLeft(d.Data[insertAt:], " ")
+	if len(rest) == 0 || rest[0] != '}' {
+		return nil, false
+	}
+	return splice(d.Data, insertAt, insertAt, entry), true
+}
+
+// flowScalarEnd returns the offset just past a single-line scalar inside a
+// flow collection. A plain scalar ends before the next "," or "}", which
+// scalarEnd cannot tell, so its end is found from its value instead.
+func flowScalarEnd(data []byte, start int, node *yaml.Node) (int, bool) {
+	if node.Kind != yaml.ScalarNode {
+		return 0, false
+	}
+	if node.Style != 0 {
+		return scalarEnd(data, start, node.Style)
+	}
+	if node.Value == "" || !bytes.HasPrefix(data[start:], []byte(node.Value)) {
+		return 0, false
+	}
+	return start + len(node.Value), true
+}
+
+// insertMappingLine adds "key: value" to a block mapping. Top-level keys are
+// appended to the end of the file, nested keys are placed before the first
+// key of the mapping with the same indentation.
+func (d *kubeConfigDocument) insertMappingLine(mapping *yaml.Node, key, value string) ([]byte, bool) {
+	if mapping.Style&yaml.FlowStyle != 0 || len(mapping.Content) == 0 {
+		return nil, false
+	}
+
+	first := mapping.Content[0]
+	lineStart, ok := nodeOffset(d.Data, &yaml.Node{Line: first.Line, Column: 1})
+	if !ok {
+		return nil, false
+	}
+	keyStart, ok := nodeOffset(d.Data, first)
+	if !ok {
+		return nil, false
+	}
+	indent := string(d.Data[lineStart:keyStart])
+	if strings.Trim(indent, " ") != "" {
+		return nil, false
+	}
+
+	line := indent + key + ": " + renderScalar(value, 0) + "\n"
+
+	if mapping == d.mapping() && indent == "" {
+		data := d.Data
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			line = "\n" + line
+		}
+		return splice(data, len(data), len(data), line), true
+	}
+
+	return splice(d.Data, lineStart, lineStart, line), true
+}
+
+// nodeOffset converts the 1-based line and column of a node into a byte offset
+func nodeOffset(data []byte, node *yaml.Node) (int, bool) {
+	if node.Line < 1 || node.Column < 1 {
+		return 0, false
+	}
+
+	offset := 0
+	for line := 1; line < node.Line; line++ {
+		next := bytes.IndexByte(data[offset:], '\n')
+		if next < 0 {
+			return 0, false
+		}
+		offset += next + 1
+	}
+
+	// Columns are counted in characters, not bytes
+	for column := 1; column < node.Column; column++ {
+		if offset >= len(data) || data[offset] == '\n' {
+			return 0, false
+		}
+		_, size := utf8.DecodeRune(data[offset:])
+		offset += size
+	}
+
+	return offset, true
+}
+
+// scalarEnd returns the offset just past a single-line scalar starting at start
+func scalarEnd(data []byte, start int, style yaml.Style) (int, bool) {
+	switch style {
+	case yaml.DoubleQuotedStyle:
+		for i := start + 1; i < len(data) && data[i] != '\n'; i++ {
+			switch data[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, true
+			}
+		}
+		return 0, false
+	case yaml.SingleQuotedStyle:
+		for i := start + 1; i < len(data) && data[i] != '\n'; i++ {
+			if data[i] == '\'' {
+				if i+1 < len(data) && data[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1, true
+			}
+		}
+		return 0, false
+	case 0:
+		end := start
+		for end < len(data) && data[end] != '\n' {
+			if data[end] == '#' && end > start && (data[end-1] == ' ' || data[end-1] == '\t') {
+				break
+			}
+			end++
+		}
+		for end > start && (data[end-1] == ' ' || data[end-1] == '\t' || data[end-1] == '\r') {
+			end--
+		}
+		return end, true
+	default:
+		return 0, false
+	}
+}
+
+// renderScalar formats a value the way it should appear in the file, keeping
+// the quoting style of the value it replaces
+func renderScalar(value string, style yaml.Style) string {
+	if style != yaml.DoubleQuotedStyle && style != yaml.SingleQuotedStyle {
+		style = 0
+	}
+	if strings.ContainsAny(value, "\n\r") {
+		style = yaml.DoubleQuotedStyle
+	}
+
+	node := yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: style}
+	out, err := yaml.Marshal(&node)
+	if err != nil {
+		return fmt.Sprintf("%q", value)
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+func splice(data []byte, start, end int, replacement string) []byte {
+	result := make([]byte, 0, len(data)-(end-start)+len(replacement))
+	result = append(result, data[:start]...)
+	result = append(result, replacement...)
+	result = append(result, data[end:]...)
+	return result
+}
@@ -0,0 +1,325 @@
+package utils
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ryo-nabata/kubec/internal/kubetest"
+)
+
+const losslessKubeConfig = `# managed by hand, do not reformat
+apiVersion: v1
+kind: Config
+preferences: {}
+clusters:
+- cluster:
+    server: https://example.com   # production
+    proxy-url: socks5://localhost:1080
+    tls-server-name: api.example.com
+    extensions:
+    - name: client.authentication.k8s.io/exec
+      extension:
+        audience: kubec
+  name: prod
+contexts:
+- context:
+    cluster: prod
+    user: admin
+  name: prod
+- context: {cluster: prod, user: admin}
+  name: flow
+users:
+- name: admin
+  user:
+    tokenFile: /var/run/token
+    as: someone
+current-context: prod # active
+`
+
+func TestSetCurrentContextLossless(t *testing.T) {
+	doc, err := parseKubeConfigDocument("config", []byte(losslessKubeConfig))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	if err := doc.SetCurrentContext("flow"); err != nil {
+		t.Fatalf("Failed to set current context: %v", err)
+	}
+
+	expected := strings.Replace(losslessKubeConfig, "current-context: prod # active", "current-context: flow # active", 1)
+	if string(doc.Data) != expected {
+		t.Errorf("Expected only the current-context line to change, but got:\n%s", doc.Data)
+	}
+
+	if doc.Config.CurrentContext != "flow" {
+		t.Errorf("Expected parsed current-context 'flow', but got '%s'", doc.Config.CurrentContext)
+	}
+}
+
+func TestSetCurrentContextQuotedAndEmptyValues(t *testing.T) {
+	testCases := []struct {
+		input    string
+		value    string
+		expected string
+	}{
+		{"current-context: \"old\"\n", "new", "current-context: \"new\"\n"},
+		{"current-context: 'old'\n", "new", "current-context: 'new'\n"},
+		{"current-context: \"\"\n", "new", "current-context: \"new\"\n"},
+		{"current-context:\nkind: Config\n", "new", "current-context: new\nkind: Config\n"},
+		{"current-context: old\r\nkind: Config\r\n", "new", "current-context: new\r\nkind: Config\r\n"},
+		{"current-context: old\n", "true", "current-context: \"true\"\n"},
+		{"kind: Config\n", "new", "kind: Config\ncurrent-context: new\n"},
+		{"kind: Config", "new", "kind: Config\ncurrent-context: new\n"},
+		{"", "new", "current-context: new\n"},
+	}
+
+	for _, testCase := range testCases {
+		doc, err := parseKubeConfigDocument("config", []byte(testCase.input))
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", testCase.input, err)
+		}
+
+		if err := doc.SetCurrentContext(testCase.value); err != nil {
+			t.Fatalf("Failed to set current context for %q: %v", testCase.input, err)
+		}
+
+		if string(doc.Data) != testCase.expected {
+			t.Errorf("For %q expected %q, but got %q", testCase.input, testCase.expected, doc.Data)
+		}
+
+		if doc.Config.CurrentContext != testCase.value {
+			t.Errorf("For %q expected parsed current-context %q, but got %q", testCase.input, testCase.value, doc.Config.CurrentContext)
+		}
+	}
+}
+
+func TestSetMappingValueNestedInsert(t *testing.T) {
+	input := "contexts:\n- context:\n    cluster: prod\n    user: admin\n  name: prod\n"
+
+	doc, err := parseKubeConfigDocument("config", []byte(input))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	_, contexts := mappingValue(doc.mapping(), "contexts")
+	_, info := mappingValue(contexts.Content[0], "context")
+	if err := doc.setMappingValue(info, "namespace", "kube-system"); err != nil {
+		t.Fatalf("Failed to set namespace: %v", err)
+	}
+
+	expected := "contexts:\n- context:\n    namespace: kube-system\n    cluster: prod\n    user: admin\n  name: prod\n"
+	if string(doc.Data) != expected {
+		t.Errorf("Expected %q, but got %q", expected, doc.Data)
+	}
+}
+
+func TestRemoveItemComments(t *testing.T) {
+	input := "contexts:\n# production\n# keep it\n- name: prod\n\n# development\n- name: dev\n# staging\n- name: staging\n"
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{"prod", "contexts:\n\n# development\n- name: dev\n# staging\n- name: staging\n"},
+		{"dev", "contexts:\n# production\n# keep it\n- name: prod\n\n# staging\n- name: staging\n"},
+		{"staging", "contexts:\n# production\n# keep it\n- name: prod\n\n# development\n- name: dev\n"},
+	}
+
+	for _, tc := range testCases {
+		doc, err := parseKubeConfigDocument("config", []byte(input))
+		if err != nil {
+			t.Fatalf("Failed to parse document: %v", err)
+		}
+		if err := doc.RemoveItem("contexts", tc.name); err != nil {
+			t.Fatalf("Failed to remove %s: %v", tc.name, err)
+		}
+		if string(doc.Data) != tc.expected {
+			t.Errorf("Removing %s: expected %q, but got %q", tc.name, tc.expected, doc.Data)
+		}
+	}
+}
+
+func TestLastItemKeepsTrailingComments(t *testing.T) {
+	input := "contexts:\n- name: prod\n- name: dev\n\n# trailing comment\n"
+
+	doc, err := parseKubeConfigDocument("config", []byte(input))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	if err := doc.RemoveItem("contexts", "dev"); err != nil {
+		t.Fatalf("Failed to remove dev: %v", err)
+	}
+	if expected := "contexts:\n- name: prod\n\n# trailing comment\n"; string(doc.Data) != expected {
+		t.Errorf("Removing the last entry: expected %q, but got %q", expected, doc.Data)
+	}
+
+	doc, err = parseKubeConfigDocument("config", []byte(input))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	if err := doc.CopyContext("dev", "dev-copy", ""); err != nil {
+		t.Fatalf("Failed to copy dev: %v", err)
+	}
+	if expected := "contexts:\n- name: prod\n- name: dev\n- name: dev-copy\n\n# trailing comment\n"; string(doc.Data) != expected {
+		t.Errorf("Copying the last entry: expected %q, but got %q", expected, doc.Data)
+	}
+
+	doc, err = parseKubeConfigDocument("config", []byte(input))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	other, err := parseKubeConfigDocument("other", []byte("contexts:\n- name: staging\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	if err := doc.AppendItem("contexts", other.namedItem("contexts", "staging")); err != nil {
+		t.Fatalf("Failed to append staging: %v", err)
+	}
+	if expected := "contexts:\n- name: prod\n- name: dev\n- name: staging\n\n# trailing comment\n"; string(doc.Data) != expected {
+		t.Errorf("Appending after the last entry: expected %q, but got %q", expected, doc.Data)
+	}
+}
+
+func TestSetMappingValueFlowFallback(t *testing.T) {
+	input := "# keep me\n{kind: Config, unknown-field: 1}\n"
+
+	doc, err := parseKubeConfigDocument("config", []byte(input))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	if err := doc.SetCurrentContext("new"); err != nil {
+		t.Fatalf("Failed to set current context: %v", err)
+	}
+
+	for _, expected := range []string{"# keep me", "unknown-field: 1", "current-context: new"} {
+		if !strings.Contains(string(doc.Data), expected) {
+			t.Errorf("Expected output to contain %q, but got:\n%s", expected, doc.Data)
+		}
+	}
+}
+
+func TestSetContextNamespaceFlowContext(t *testing.T) {
+	input := `contexts:
+- context: {cluster: b, user: u}  # flow style
+  name: flow
+- context: {cluster: b, namespace: "old"}
+  name: quoted
+- context: {}
+  name: empty
+users:
+    - name: u   # indented list
+      user: {}
+`
+	tests := []struct {
+		context, namespace, line string
+	}{
+		{"flow", "payments", "- context: {cluster: b, user: u, namespace: payments}  # flow style\n"},
+		{"quoted", "new", `- context: {cluster: b, namespace: "new"}` + "\n"},
+		{"empty", "a,b", `- context: {namespace: "a,b"}` + "\n"},
+	}
+	for _, test := range tests {
+		doc, err := parseKubeConfigDocument("config", []byte(input))
+		if err != nil {
+			t.Fatalf("Failed to parse document: %v", err)
+		}
+		if err := doc.SetContextNamespace(test.context, test.namespace); err != nil {
+			t.Fatalf("Failed to set namespace of %s: %v", test.context, err)
+		}
+
+		// Only the line of the context changes
+		inputLines := strings.SplitAfter(input, "\n")
+		outputLines := strings.SplitAfter(string(doc.Data), "\n")
+		if len(outputLines) != len(inputLines) {
+			t.Fatalf("Expected %d lines, but got:\n%s", len(inputLines), doc.Data)
+		}
+		for i := range inputLines {
+			if outputLines[i] != inputLines[i] && outputLines[i] != test.line {
+				t.Errorf("Expected only %q to change, but got %q", test.line, outputLines[i])
+			}
+		}
+		if !strings.Contains(string(doc.Data), test.line) {
+			t.Errorf("Expected output to contain %q, but got:\n%s", test.line, doc.Data)
+		}
+		if context := doc.Config.FindContext(test.context); context == nil || context.Context.Namespace != test.namespace {
+			t.Errorf("Expected namespace %s, but got %+v", test.namespace, context)
+		}
+	}
+}
+
+func TestSetCurrentContextFileLossless(t *testing.T) {
+	configPath := kubetest.WriteKubeConfig(t, losslessKubeConfig)
+
+	if err := SetCurrentContext("flow"); err != nil {
+		t.Fatalf("Failed to set current context: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+
+	expected := strings.Replace(losslessKubeConfig, "current-context: prod", "current-context: flow", 1)
+	if string(data) != expected {
+		t.Errorf("Expected a single-line change, but got:\n%s", data)
+	}
+}
+
+func TestRedactedData(t *testing.T) {
+	data := `users:
+- name: token-user # keep this comment
+  user:
+    token: "hidden-token"
+    client-key-data: c2VjcmV0
+- name: oidc
+  user:
+    auth-provider:
+      name: oidc
+      config: {client-id: kubec, client-secret: hidden-client, id-token: hidden-id}
+- name: plugin
+  user:
+    exec:
+      command: aws
+      env:
+      - name: AWS_PROFILE
+        value: hidden-profile
+contexts:
+- name: token
+  context: {cluster: c, user: token-user}
+`
+	doc, err := parseKubeConfigDocument("config", []byte(data))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	redacted, err := doc.redactedData()
+	if err != nil {
+		t.Fatalf("Failed to redact document: %v", err)
+	}
+	if strings.Contains(string(redacted), "hidden") {
+		t.Errorf("Expected every secret to be redacted, but got:\n%s", redacted)
+	}
+	for _, expected := range []string{`token: "REDACTED"`, "client-key-data: REDACTED", "client-id: kubec", "# keep this comment", "name: token\n"} {
+		if !strings.Contains(string(redacted), expected) {
+			t.Errorf("Expected output to contain %q, but got:\n%s", expected, redacted)
+		}
+	}
+	if strings.Count(string(redacted), "\n") != strings.Count(data, "\n") {
+		t.Errorf("Expected the line count to stay the same, but got:\n%s", redacted)
+	}
+}
+
+func TestRedactedDataMultiLineValue(t *testing.T) {
+	doc, err := parseKubeConfigDocument("config", []byte("users:\n- name: a\n  user:\n    token: |\n      secret-token\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	redacted, err := doc.redactedData()
+	if err != nil {
+		t.Fatalf("Failed to redact document: %v", err)
+	}
+	if strings.Contains(string(redacted), "secret-token") || !strings.Contains(string(redacted), "token: REDACTED") {
+		t.Errorf("Expected the token to be redacted, but got:\n%s", redacted)
+	}
+}
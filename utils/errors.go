@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var (
+	// ErrKubeconfigNotFound is returned when none of the kubeconfig files exist
+	ErrKubeconfigNotFound = errors.New("kubeconfig file not found")
+
+	// ErrContextNotFound matches ContextNotFoundError with errors.Is
+	ErrContextNotFound = errors.New("context not found")
+)
+
+// ContextNotFoundError is returned when no kubeconfig file defines a context
+type ContextNotFoundError struct {
+	Name string
+}
+
+func (e *ContextNotFoundError) Error() string {
+	return fmt.Sprintf("context '%s' not found", e.Name)
+}
+
+func (e *ContextNotFoundError) Is(target error) bool {
+	return target == ErrContextNotFound
+}
+
+// ParseError is returned when a kubeconfig file is not valid YAML or does not
+// have the structure of a kubeconfig. Line is 1-based and 0 when yaml.v3 does
+// not report a position.
+type ParseError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("failed to parse kubeconfig file %s: %v", e.Path, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+func newParseError(path string, err error) *ParseError {
+	parseErr := &ParseError{Path: path, Err: err}
+	// yaml.v3 only has the line in its messages
+	if match := yamlLinePattern.FindStringSubmatch(err.Error()); match != nil {
+		parseErr.Line, _ = strconv.Atoi(match[1])
+	}
+	return parseErr
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ryo-nabata/kubec/internal/kubetest"
+)
+
+func TestErrKubeconfigNotFound(t *testing.T) {
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+
+	_, err := GetContexts()
+	if !errors.Is(err, ErrKubeconfigNotFound) {
+		t.Errorf("Expected ErrKubeconfigNotFound, but got %v", err)
+	}
+}
+
+func TestErrContextNotFound(t *testing.T) {
+	kubetest.WriteKubeConfig(t, marshalTestKubeConfig(t, KubeConfig{
+		Contexts: []Context{{Name: "dev", Context: ContextInfo{Cluster: "dev"}}},
+	}))
+
+	err := SetCurrentContext("prod")
+	if !errors.Is(err, ErrContextNotFound) {
+		t.Fatalf("Expected ErrContextNotFound, but got %v", err)
+	}
+	var notFound *ContextNotFoundError
+	if !errors.As(err, &notFound) || notFound.Name != "prod" {
+		t.Errorf("Expected ContextNotFoundError for prod, but got %v", err)
+	}
+	if err.Error() != "context 'prod' not found" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+}
+
+func TestParseError(t *testing.T) {
+	configPath := kubetest.WriteKubeConfig(t, "apiVersion: v1\nkind: Config\ncontexts: 5\n")
+
+	_, err := GetCurrentContext()
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected ParseError, but got %v", err)
+	}
+	if parseErr.Path != configPath || parseErr.Line != 3 {
+		t.Errorf("Expected error at %s:3, but got %s:%d", configPath, parseErr.Path, parseErr.Line)
+	}
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CredentialExpiry is the expiry date of one credential a context uses.
+// Error is set instead of NotAfter when the credential cannot be read.
+type CredentialExpiry struct {
+	Context    string    `json:"context"`
+	Credential string    `json:"credential"`
+	Subject    string    `json:"subject,omitempty"`
+	NotAfter   time.Time `json:"notAfter"`
+	Error      string    `json:"error,omitempty"`
+}
+
+func (e CredentialExpiry) Expired(now time.Time) bool {
+	return e.Error == "" && !now.Before(e.NotAfter)
+}
+
+func GetCredentialExpiries() ([]CredentialExpiry, error) {
+	return DefaultClient().CredentialExpiries(context.Background())
+}
+
+// CredentialExpiries decodes the certificates and JWT tokens of every
+// context, without contacting any cluster, and returns their expiry dates
+// ordered by the soonest. Tokens that are not JWTs or carry no expiry are left
+// out.
+func (c *Client) CredentialExpiries(ctx context.Context) ([]CredentialExpiry, error) {
+	config, err := c.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiries []CredentialExpiry
+	for _, context := range config.Contexts {
+		add := func(credential, subject string, notAfter time.Time, err error) {
+			expiry := CredentialExpiry{Context: context.Name, Credential: credential, Subject: subject, NotAfter: notAfter}
+			if err != nil {
+				expiry.Error = err.Error()
+			}
+			expiries = append(expiries, expiry)
+		}
+
+		if cluster := config.FindCluster(context.Context.Cluster); cluster != nil {
+			info := cluster.Cluster
+			if info.CertificateAuthorityData != "" || info.CertificateAuthority != "" {
+				subject, notAfter, err := certificateExpiry(info.CertificateAuthorityData, info.CertificateAuthority)
+				add("certificate authority", subject, notAfter, err)
+			}
+		}
+
+		user := config.FindUser(context.Context.User)
+		if user == nil {
+			continue
+		}
+		info := user.User
+		if info.ClientCertificateData != "" || info.ClientCertificate != "" {
+			subject, notAfter, err := certificateExpiry(info.ClientCertificateData, info.ClientCertificate)
+			add("client certificate", subject, notAfter, err)
+		}
+
+		token := info.Token
+		if token == "" && info.TokenFile != "" {
+			data, err := os.ReadFile(info.TokenFile)
+			if err != nil {
+				add("token", "", time.Time{}, fmt.Errorf("failed to read token file: %w", err))
+				continue
+			}
+			token = strings.TrimSpace(string(data))
+		}
+		if token == "" && info.AuthProvider != nil {
+			if providerConfig, ok := info.AuthProvider["config"].(map[string]interface{}); ok {
+				token, _ = providerConfig["id-token"].(string)
+			}
+		}
+		if subject, notAfter, ok := tokenExpiry(token); ok {
+			add("token", subject, notAfter, nil)
+		}
+	}
+
+	// Unreadable credentials first, they need attention as much as expired ones
+	sort.SliceStable(expiries, func(i, j int) bool {
+		if (expiries[i].Error != "") != (expiries[j].Error != "") {
+			return expiries[i].Error != ""
+		}
+		return expiries[i].NotAfter.Before(expiries[j].NotAfter)
+	})
+	return expiries, nil
+}
+
+// certificateExpiry returns the subject and expiry of the certificate in a
+// data field or file. For a bundle the certificate that expires first counts.
+func certificateExpiry(data, path string) (string, time.Time, error) {
+	content, err := readDataOrFile(data, path)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read certificate: %w", err)
+	}
+
+	var earliest *x509.Certificate
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		if earliest == nil || certificate.NotAfter.Before(earliest.NotAfter) {
+			earliest = certificate
+		}
+	}
+	if earliest == nil {
+		return "", time.Time{}, fmt.Errorf("no PEM certificate found")
+	}
+	return earliest.Subject.CommonName, earliest.NotAfter, nil
+}
+
+// tokenExpiry reads the subject and exp claims of a JWT without verifying its
+// signature
+func tokenExpiry(token string) (string, time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", time.Time{}, false
+	}
+
+	var claims struct {
+		Subject string      `json:"sub"`
+		Expiry  json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Expiry == "" {
+		return "", time.Time{}, false
+	}
+	seconds, err := claims.Expiry.Float64()
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return claims.Subject, time.Unix(int64(seconds), 0), true
+}
+
+// ParseDuration is time.ParseDuration with an additional "d" unit for days,
+// so that thresholds such as "14d" can be written naturally
+func ParseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		count, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s'", value)
+		}
+		return time.Duration(count * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(value)
+}
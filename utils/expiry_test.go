@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ryo-nabata/kubec/internal/kubetest"
+)
+
+// testCertificate returns a PEM encoded self-signed certificate
+func testCertificate(t *testing.T, commonName string, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func testToken(claims string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"RS256"}`)) + "." + encode([]byte(claims)) + "." + encode([]byte("signature"))
+}
+
+func TestGetCredentialExpiries(t *testing.T) {
+	tempDir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+
+	caPath := filepath.Join(tempDir, "ca.crt")
+	bundle := append(testCertificate(t, "old-ca", now.Add(30*24*time.Hour)), testCertificate(t, "new-ca", now.Add(90*24*time.Hour))...)
+	if err := os.WriteFile(caPath, bundle, 0600); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+	clientData := base64.StdEncoding.EncodeToString(testCertificate(t, "admin", now.Add(-time.Hour)))
+
+	kubetest.WriteKubeConfig(t, marshalTestKubeConfig(t, KubeConfig{
+		Contexts: []Context{
+			{Name: "prod", Context: ContextInfo{Cluster: "prod", User: "admin"}},
+			{Name: "dev", Context: ContextInfo{Cluster: "dev", User: "dev"}},
+			{Name: "broken", Context: ContextInfo{Cluster: "broken", User: "opaque"}},
+		},
+		Clusters: []Cluster{
+			{Name: "prod", Cluster: ClusterInfo{Server: "https://prod", CertificateAuthority: caPath}},
+			{Name: "dev", Cluster: ClusterInfo{Server: "https://dev"}},
+			{Name: "broken", Cluster: ClusterInfo{Server: "https://broken", CertificateAuthorityData: "bm90IGEgY2VydA=="}},
+		},
+		Users: []User{
+			{Name: "admin", User: UserInfo{ClientCertificateData: clientData}},
+			{Name: "dev", User: UserInfo{Token: testToken(fmt.Sprintf(`{"sub":"system:serviceaccount:ci","exp":%d}`, now.Add(48*time.Hour).Unix()))}},
+			{Name: "opaque", User: UserInfo{Token: "not-a-jwt"}},
+		},
+	}))
+
+	expiries, err := GetCredentialExpiries()
+	if err != nil {
+		t.Fatalf("Failed to get credential expiries: %v", err)
+	}
+
+	expected := []CredentialExpiry{
+		{Context: "broken", Credential: "certificate authority"},
+		{Context: "prod", Credential: "client certificate", Subject: "admin", NotAfter: now.Add(-time.Hour)},
+		{Context: "dev", Credential: "token", Subject: "system:serviceaccount:ci", NotAfter: now.Add(48 * time.Hour)},
+		{Context: "prod", Credential: "certificate authority", Subject: "old-ca", NotAfter: now.Add(30 * 24 * time.Hour)},
+	}
+	if len(expiries) != len(expected) {
+		t.Fatalf("Expected %d expiries, but got %+v", len(expected), expiries)
+	}
+	for i, expiry := range expiries {
+		if expiry.Context != expected[i].Context || expiry.Credential != expected[i].Credential ||
+			expiry.Subject != expected[i].Subject || !expiry.NotAfter.Equal(expected[i].NotAfter) {
+			t.Errorf("Expected expiry %d to be %+v, but got %+v", i, expected[i], expiry)
+		}
+	}
+	if expiries[0].Error == "" {
+		t.Error("Expected an error for the unparsable certificate authority")
+	}
+	if !expiries[1].Expired(now) || expiries[2].Expired(now) {
+		t.Error("Expected only the client certificate to be expired")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"14d":  14 * 24 * time.Hour,
+		"1.5d": 36 * time.Hour,
+		"12h":  12 * time.Hour,
+	}
+	for value, expected := range tests {
+		duration, err := ParseDuration(value)
+		if err != nil || duration != expected {
+			t.Errorf("Expected ParseDuration(%q) to be %v, but got %v (%v)", value, expected, duration, err)
+		}
+	}
+	if _, err := ParseDuration("xd"); err == nil {
+		t.Error("Expected an error for an invalid number of days")
+	}
+}
@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type ExportOptions struct {
+	// Flatten inlines certificate and key files into the *-data fields, and
+	// token files into token
+	Flatten bool
+
+	// Minify exports only the current context when no context is named
+	Minify bool
+
+	// Format is yaml, json or base64 (base64 encoded yaml)
+	Format string
+}
+
+var ExportFormats = []string{"yaml", "json", "base64"}
+
+// IsExportFormat reports whether format is one of ExportFormats
+func IsExportFormat(format string) bool {
+	for _, exportFormat := range ExportFormats {
+		if format == exportFormat {
+			return true
+		}
+	}
+	return false
+}
+
+// fileField is a field that holds a file path, and the field its content is
+// inlined into. Plain fields take the text of the file instead of base64.
+type fileField struct {
+	path, data string
+	plain      bool
+}
+
+var fileFields = map[string][]fileField{
+	"cluster": {{path: "certificate-authority", data: "certificate-authority-data"}},
+	"user": {
+		{path: "client-certificate", data: "client-certificate-data"},
+		{path: "client-key", data: "client-key-data"},
+		{path: "tokenFile", data: "token", plain: true},
+	},
+}
+
+func ExportKubeConfig(names []string, options ExportOptions) ([]byte, error) {
+	return DefaultClient().Export(context.Background(), names, options)
+}
+
+// Export builds a self-contained kubeconfig holding the named contexts and
+// only the clusters and users they reference. Entries are copied from the
+// files with every field they have, not only the ones KubeConfig models.
+// Without names all contexts are exported, or only the current one with
+// Minify.
+func (c *Client) Export(ctx context.Context, names []string, options ExportOptions) ([]byte, error) {
+	files, err := c.loadFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config := mergeKubeConfigs(files)
+
+	if len(names) == 0 {
+		if options.Minify {
+			if config.CurrentContext == "" {
+				return nil, fmt.Errorf("no current context is set")
+			}
+			names = []string{config.CurrentContext}
+		} else {
+			for _, context := range config.Contexts {
+				names = append(names, context.Name)
+			}
+		}
+	}
+
+	contexts := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	clusters := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	users := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	added := make(map[string]bool)
+
+	currentContext := ""
+	for _, name := range names {
+		context := config.FindContext(name)
+		if context == nil {
+			return nil, &ContextNotFoundError{Name: name}
+		}
+		if added["context/"+name] {
+			continue
+		}
+		added["context/"+name] = true
+		if currentContext == "" || name == config.CurrentContext {
+			currentContext = name
+		}
+
+		item, _ := exportItem(files, "contexts", name)
+		contexts.Content = append(contexts.Content, item)
+
+		references := []struct{ listKey, kind, name string }{
+			{"clusters", "cluster", context.Context.Cluster},
+			{"users", "user", context.Context.User},
+		}
+		for _, reference := range references {
+			if reference.name == "" || added[reference.kind+"/"+reference.name] {
+				continue
+			}
+			item, dir := exportItem(files, reference.listKey, reference.name)
+			if item == nil {
+				return nil, fmt.Errorf("%s '%s' of context '%s' not found", reference.kind, reference.name, name)
+			}
+			added[reference.kind+"/"+reference.name] = true
+
+			_, info := mappingValue(item, reference.kind)
+			if err := exportFileFields(info, fileFields[reference.kind], dir, options.Flatten); err != nil {
+				return nil, fmt.Errorf("failed to flatten %s '%s': %w", reference.kind, reference.name, err)
+			}
+			if reference.kind == "cluster" {
+				clusters.Content = append(clusters.Content, item)
+			} else {
+				users.Content = append(users.Content, item)
+			}
+		}
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, field := range []struct {
+		key   string
+		value *yaml.Node
+	}{
+		{"apiVersion", scalarNode("v1")},
+		{"kind", scalarNode("Config")},
+		{"clusters", clusters},
+		{"contexts", contexts},
+		{"users", users},
+		{"current-context", scalarNode(currentContext)},
+	} {
+		root.Content = append(root.Content, scalarNode(field.key), field.value)
+	}
+
+	return encodeExport(root, options.Format)
+}
+
+// exportItem returns a copy of the first definition of name in a list,
+// without comments, and the directory of the file that defines it
+func exportItem(files []*kubeConfigDocument, listKey, name string) (*yaml.Node, string) {
+	for _, file := range files {
+		if item := file.namedItem(listKey, name); item != nil {
+			copied := deepCopyNode(item)
+			stripComments(copied)
+			return copied, filepath.Dir(file.Path)
+		}
+	}
+	return nil, ""
+}
+
+func stripComments(node *yaml.Node) {
+	node.HeadComment = ""
+	node.LineComment = ""
+	node.FootComment = ""
+	for _, child := range node.Content {
+		stripComments(child)
+	}
+}
+
+// exportFileFields makes file references usable outside of the kubeconfig
+// directory: relative paths become absolute, and with flatten the files are
+// inlined into their *-data fields, or a token file into token
+func exportFileFields(info *yaml.Node, fields []fileField, dir string, flatten bool) error {
+	if info == nil || info.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for _, field := range fields {
+		for i := 0; i+1 < len(info.Content); i += 2 {
+			key, value := info.Content[i], info.Content[i+1]
+			if key.Value != field.path || value.Value == "" {
+				continue
+			}
+
+			path := value.Value
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			if !flatten {
+				value.Value = path
+				break
+			}
+
+			// Inline data takes precedence over the file, as in kubectl
+			if _, data := mappingValue(info, field.data); data == nil {
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				value := base64.StdEncoding.EncodeToString(content)
+				if field.plain {
+					value = strings.TrimSpace(string(content))
+				}
+				info.Content = append(info.Content, scalarNode(field.data), scalarNode(value))
+			}
+			info.Content = append(info.Content[:i], info.Content[i+2:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func encodeExport(root *yaml.Node, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return nil, fmt.Errorf("failed to encode kubeconfig: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode kubeconfig: %w", err)
+	}
+
+	switch format {
+	case "", "yaml":
+		return buf.Bytes(), nil
+	case "base64":
+		return []byte(base64.StdEncoding.EncodeToString(buf.Bytes()) + "\n"), nil
+	case "json":
+		var value interface{}
+		if err := root.Decode(&value); err != nil {
+			return nil, fmt.Errorf("failed to encode kubeconfig: %w", err)
+		}
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode kubeconfig: %w", err)
+		}
+		return append(data, '\n'), nil
+	default:
+		return nil, fmt.Errorf("unknown output format '%s', use yaml, json or base64", format)
+	}
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ryo-nabata/kubec/internal/kubetest"
+	"gopkg.in/yaml.v3"
+)
+
+const exportKubeConfig = `apiVersion: v1
+kind: Config
+# Production
+clusters:
+- cluster:
+    server: https://prod.example.com
+    certificate-authority: certs/ca.crt
+    proxy-url: http://proxy:3128
+  name: prod
+- cluster:
+    server: https://dev.example.com
+  name: dev
+contexts:
+- context:
+    cluster: prod
+    user: admin
+    namespace: payments
+  name: prod
+- context:
+    cluster: dev
+    user: dev
+  name: dev
+- context:
+    cluster: dev
+    user: ci
+  name: ci
+users:
+- name: admin
+  user:
+    client-certificate: certs/admin.crt
+    client-key: certs/admin.key
+- name: dev
+  user:
+    token: dev-token
+- name: ci
+  user:
+    tokenFile: certs/ci.token
+current-context: dev
+`
+
+func writeExportKubeConfig(t *testing.T) string {
+	t.Helper()
+	tempDir := filepath.Dir(kubetest.WriteKubeConfig(t, exportKubeConfig))
+	if err := os.MkdirAll(filepath.Join(tempDir, "certs"), 0700); err != nil {
+		t.Fatalf("Failed to create certs directory: %v", err)
+	}
+	for name, content := range map[string]string{"ca.crt": "ca", "admin.crt": "cert", "admin.key": "key", "ci.token": "ci-token\n"} {
+		if err := os.WriteFile(filepath.Join(tempDir, "certs", name), []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return tempDir
+}
+
+func TestExportKubeConfig(t *testing.T) {
+	tempDir := writeExportKubeConfig(t)
+
+	data, err := ExportKubeConfig([]string{"prod"}, ExportOptions{})
+	if err != nil {
+		t.Fatalf("Failed to export context: %v", err)
+	}
+
+	var exported KubeConfig
+	if err := yaml.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("Failed to parse exported kubeconfig: %v", err)
+	}
+	if len(exported.Contexts) != 1 || len(exported.Clusters) != 1 || len(exported.Users) != 1 {
+		t.Fatalf("Expected only prod and what it references, but got:\n%s", data)
+	}
+	if exported.CurrentContext != "prod" || exported.Contexts[0].Context.Namespace != "payments" {
+		t.Errorf("Expected current context prod with namespace payments, but got:\n%s", data)
+	}
+	if expected := filepath.Join(tempDir, "certs", "ca.crt"); exported.Clusters[0].Cluster.CertificateAuthority != expected {
+		t.Errorf("Expected absolute CA path %s, but got %s", expected, exported.Clusters[0].Cluster.CertificateAuthority)
+	}
+	// Fields KubeConfig does not model are kept, comments are not
+	if !strings.Contains(string(data), "proxy-url: http://proxy:3128") || strings.Contains(string(data), "# Production") {
+		t.Errorf("Expected proxy-url without comments, but got:\n%s", data)
+	}
+}
+
+func TestExportKubeConfigFlatten(t *testing.T) {
+	writeExportKubeConfig(t)
+
+	data, err := ExportKubeConfig([]string{"prod"}, ExportOptions{Flatten: true})
+	if err != nil {
+		t.Fatalf("Failed to export context: %v", err)
+	}
+
+	var exported KubeConfig
+	if err := yaml.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("Failed to parse exported kubeconfig: %v", err)
+	}
+	cluster, user := exported.Clusters[0].Cluster, exported.Users[0].User
+	if cluster.CertificateAuthority != "" || user.ClientCertificate != "" || user.ClientKey != "" {
+		t.Errorf("Expected no file references after flattening, but got:\n%s", data)
+	}
+	for value, expected := range map[string]string{
+		cluster.CertificateAuthorityData: "ca",
+		user.ClientCertificateData:       "cert",
+		user.ClientKeyData:               "key",
+	} {
+		if decoded, _ := base64.StdEncoding.DecodeString(value); string(decoded) != expected {
+			t.Errorf("Expected inlined %q, but got %q", expected, decoded)
+		}
+	}
+}
+
+func TestExportKubeConfigTokenFile(t *testing.T) {
+	tempDir := writeExportKubeConfig(t)
+
+	data, err := ExportKubeConfig([]string{"ci"}, ExportOptions{})
+	if err != nil {
+		t.Fatalf("Failed to export context: %v", err)
+	}
+	var exported KubeConfig
+	if err := yaml.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("Failed to parse exported kubeconfig: %v", err)
+	}
+	if expected := filepath.Join(tempDir, "certs", "ci.token"); exported.Users[0].User.TokenFile != expected {
+		t.Errorf("Expected absolute token file path %s, but got:\n%s", expected, data)
+	}
+
+	data, err = ExportKubeConfig([]string{"ci"}, ExportOptions{Flatten: true})
+	if err != nil {
+		t.Fatalf("Failed to export context: %v", err)
+	}
+	exported = KubeConfig{}
+	if err := yaml.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("Failed to parse exported kubeconfig: %v", err)
+	}
+	if user := exported.Users[0].User; user.TokenFile != "" || user.Token != "ci-token" {
+		t.Errorf("Expected the token file inlined into token, but got:\n%s", data)
+	}
+}
+
+func TestExportKubeConfigFormats(t *testing.T) {
+	writeExportKubeConfig(t)
+
+	// Minify without names exports the current context
+	data, err := ExportKubeConfig(nil, ExportOptions{Minify: true, Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to export context: %v", err)
+	}
+	var exported struct {
+		Contexts []struct {
+			Name string `json:"name"`
+		} `json:"contexts"`
+	}
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("Failed to parse exported JSON: %v", err)
+	}
+	if len(exported.Contexts) != 1 || exported.Contexts[0].Name != "dev" {
+		t.Errorf("Expected only the current context dev, but got:\n%s", data)
+	}
+
+	encoded, err := ExportKubeConfig(nil, ExportOptions{Format: "base64"})
+	if err != nil {
+		t.Fatalf("Failed to export contexts: %v", err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil || !strings.Contains(string(decoded), "name: prod") || !strings.Contains(string(decoded), "name: dev") {
+		t.Errorf("Expected base64 of all contexts, but got %s (%v)", decoded, err)
+	}
+
+	if _, err := ExportKubeConfig([]string{"missing"}, ExportOptions{}); err == nil {
+		t.Error("Expected an error for a missing context")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package utils
+
+import "os"
+
+func preserveOwner(file *os.File, info os.FileInfo) {}
+
+func syncDirectory(dir string) {}
+
+// sessionAlive cannot check the owner process portably, so a session is
+// kept until it expires
+func sessionAlive(dir string, owner int) bool { return !sessionExpired(dir) }
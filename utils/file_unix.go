@@ -0,0 +1,30 @@
+//go:build unix
+
+package utils
+
+import (
+	"os"
+	"syscall"
+)
+
+// preserveOwner copies the owner of the original file. It is best effort:
+// only root can give a file away, and a file we own needs no change.
+func preserveOwner(file *os.File, info os.FileInfo) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		file.Chown(int(stat.Uid), int(stat.Gid))
+	}
+}
+
+// syncDirectory flushes the directory entry after a rename
+func syncDirectory(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// sessionAlive reports whether the process owning a session still exists
+func sessionAlive(dir string, owner int) bool {
+	err := syscall.Kill(owner, 0)
+	return err == nil || err == syscall.EPERM
+}
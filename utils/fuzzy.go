@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Scores used to rank fuzzy matches
+const (
+	fuzzyMatchScore       = 16
+	fuzzyConsecutiveBonus = 12
+	fuzzyBoundaryBonus    = 8
+	fuzzyPrefixBonus      = 20
+	fuzzyGapPenalty       = 2
+)
+
+// FuzzyMatch reports whether the characters of pattern appear in text in
+// order (case-insensitive). It returns a score, higher is better, and the
+// rune positions of the matched characters for highlighting.
+func FuzzyMatch(pattern, text string) (int, []int, bool) {
+	pattern = strings.ToLower(pattern)
+	if pattern == "" {
+		return 0, nil, true
+	}
+
+	needle := []rune(pattern)
+	original := []rune(text)
+	haystack := []rune(strings.ToLower(text))
+
+	bestScore := 0
+	var bestPositions []int
+	found := false
+
+	for start := range haystack {
+		if haystack[start] != needle[0] {
+			continue
+		}
+
+		// Find the first complete match from start
+		end := -1
+		n := 0
+		for i := start; i < len(haystack); i++ {
+			if haystack[i] == needle[n] {
+				n++
+				if n == len(needle) {
+					end = i
+					break
+				}
+			}
+		}
+		if end < 0 {
+			// No later start can match either
+			break
+		}
+
+		// Walk back from the end to get the tightest window
+		positions := make([]int, len(needle))
+		n = len(needle) - 1
+		for i := end; i >= start && n >= 0; i-- {
+			if haystack[i] == needle[n] {
+				positions[n] = i
+				n--
+			}
+		}
+
+		score := scoreFuzzyMatch(original, positions)
+		if !found || score > bestScore {
+			bestScore = score
+			bestPositions = positions
+			found = true
+		}
+	}
+
+	return bestScore, bestPositions, found
+}
+
+func scoreFuzzyMatch(text []rune, positions []int) int {
+	score := 0
+	for i, position := range positions {
+		score += fuzzyMatchScore
+		if i > 0 {
+			if position == positions[i-1]+1 {
+				score += fuzzyConsecutiveBonus
+			} else {
+				score -= (position - positions[i-1] - 1) * fuzzyGapPenalty
+			}
+		}
+		if position == 0 {
+			score += fuzzyPrefixBonus
+		} else if isWordBoundary(text[position-1], text[position]) {
+			score += fuzzyBoundaryBonus
+		}
+	}
+	return score
+}
+
+func isWordBoundary(previous, current rune) bool {
+	if !unicode.IsLetter(previous) && !unicode.IsDigit(previous) {
+		return true
+	}
+	// camelCase
+	return unicode.IsLower(previous) && unicode.IsUpper(current)
+}
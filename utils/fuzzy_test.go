@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	testCases := []struct {
+		pattern   string
+		text      string
+		matches   bool
+		positions []int
+	}{
+		{"", "anything", true, nil},
+		{"prod", "prod", true, []int{0, 1, 2, 3}},
+		{"PROD", "payments-prod", true, []int{9, 10, 11, 12}},
+		{"pp", "payments-prod", true, []int{0, 9}},
+		{"eu1pay", "arn:aws:eks:eu-west-1:123456789012:cluster/payments-prod", true, nil},
+		{"dorp", "prod", false, nil},
+		{"prodx", "prod", false, nil},
+	}
+
+	for _, testCase := range testCases {
+		_, positions, ok := FuzzyMatch(testCase.pattern, testCase.text)
+		if ok != testCase.matches {
+			t.Errorf("FuzzyMatch(%q, %q): expected match %v, but got %v", testCase.pattern, testCase.text, testCase.matches, ok)
+			continue
+		}
+		if testCase.positions == nil {
+			continue
+		}
+		if len(positions) != len(testCase.positions) {
+			t.Errorf("FuzzyMatch(%q, %q): expected positions %v, but got %v", testCase.pattern, testCase.text, testCase.positions, positions)
+			continue
+		}
+		for i := range positions {
+			if positions[i] != testCase.positions[i] {
+				t.Errorf("FuzzyMatch(%q, %q): expected positions %v, but got %v", testCase.pattern, testCase.text, testCase.positions, positions)
+				break
+			}
+		}
+	}
+}
+
+func TestFuzzyMatchRanking(t *testing.T) {
+	// Better matches score higher
+	rankings := []struct {
+		pattern string
+		better  string
+		worse   string
+	}{
+		{"prod", "prod", "payments-rod"},
+		{"prod", "prod-eu", "eu-prod"},
+		{"pay", "payments", "p-a-y"},
+		{"ep", "eu-prod", "deeper"},
+	}
+
+	for _, ranking := range rankings {
+		betterScore, _, ok := FuzzyMatch(ranking.pattern, ranking.better)
+		if !ok {
+			t.Fatalf("Expected %q to match %q", ranking.pattern, ranking.better)
+		}
+		worseScore, _, ok := FuzzyMatch(ranking.pattern, ranking.worse)
+		if !ok {
+			t.Fatalf("Expected %q to match %q", ranking.pattern, ranking.worse)
+		}
+		if betterScore <= worseScore {
+			t.Errorf("Expected %q to rank %q (%d) above %q (%d)", ranking.pattern, ranking.better, betterScore, ranking.worse, worseScore)
+		}
+	}
+}
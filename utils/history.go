@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxHistoryEntries bounds the history file, older switches are dropped
+const maxHistoryEntries = 100
+
+type HistoryEntry struct {
+	Time     time.Time `json:"time"`
+	Context  string    `json:"context"`
+	Previous string    `json:"previous,omitempty"`
+}
+
+func getHistoryPath() (string, error) {
+	stateDir, err := GetStateDirectory()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "history"), nil
+}
+
+// GetHistory returns the recorded context switches, oldest first
+func GetHistory() ([]HistoryEntry, error) {
+	historyPath, err := getHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(historyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer file.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		// Skip lines that cannot be parsed instead of losing the whole history
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Context == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetPreviousContext returns the context to go back to, like "cd -"
+func GetPreviousContext(currentContext string) string {
+	entries, err := GetHistory()
+	if err != nil || len(entries) == 0 {
+		return ""
+	}
+
+	last := entries[len(entries)-1]
+	// The context was changed by another tool since the last switch
+	if last.Context != currentContext {
+		return last.Context
+	}
+	return last.Previous
+}
+
+// recordSwitch appends a switch to the history. The file is rewritten under
+// its lock so concurrent kubec processes never interleave or lose entries.
+func recordSwitch(previous, contextName string) error {
+	historyPath, err := getHistoryPath()
+	if err != nil {
+		return err
+	}
+	if err := CreateDirectoryIfNotExists(filepath.Dir(historyPath)); err != nil {
+		return err
+	}
+
+	unlock, err := LockFile(historyPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := GetHistory()
+	if err != nil {
+		return err
+	}
+	entries = append(entries, HistoryEntry{Time: time.Now(), Context: contextName, Previous: previous})
+	if len(entries) > maxHistoryEntries {
+		entries = entries[len(entries)-maxHistoryEntries:]
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("failed to prepare history write: %w", err)
+		}
+	}
+	return WriteFileAtomic(historyPath, buf.Bytes(), 0600)
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ryo-nabata/kubec/internal/kubetest"
+)
+
+func TestSetCurrentContextRecordsHistory(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	kubetest.WriteKubeConfig(t, marshalTestKubeConfig(t, KubeConfig{
+		CurrentContext: "context-a",
+		Contexts:       []Context{{Name: "context-a"}, {Name: "context-b"}},
+	}))
+
+	if err := SetCurrentContext("context-b"); err != nil {
+		t.Fatalf("Failed to set current context: %v", err)
+	}
+
+	entries, err := GetHistory()
+	if err != nil {
+		t.Fatalf("Failed to read history: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Context != "context-b" || entries[0].Previous != "context-a" {
+		t.Fatalf("Expected one switch from context-a to context-b, but got %+v", entries)
+	}
+	if entries[0].Time.IsZero() {
+		t.Error("Expected history entry to have a timestamp")
+	}
+
+	if previous := GetPreviousContext("context-b"); previous != "context-a" {
+		t.Errorf("Expected previous context 'context-a', but got '%s'", previous)
+	}
+
+	// Switching to the same context is not a switch
+	if err := SetCurrentContext("context-b"); err != nil {
+		t.Fatalf("Failed to set current context: %v", err)
+	}
+	entries, _ = GetHistory()
+	if len(entries) != 1 {
+		t.Errorf("Expected 1 history entry, but got %d", len(entries))
+	}
+}
+
+func TestGetPreviousContextAfterExternalSwitch(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	if err := recordSwitch("context-a", "context-b"); err != nil {
+		t.Fatalf("Failed to record switch: %v", err)
+	}
+
+	// Someone ran "kubectl config use-context context-c" in between
+	if previous := GetPreviousContext("context-c"); previous != "context-b" {
+		t.Errorf("Expected previous context 'context-b', but got '%s'", previous)
+	}
+}
+
+func TestHistoryIsBoundedAndSafeForConcurrentWriters(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	var wg sync.WaitGroup
+	for writer := 0; writer < 8; writer++ {
+		wg.Add(1)
+		go func(writer int) {
+			defer wg.Done()
+			for i := 0; i < 15; i++ {
+				if err := recordSwitch("", fmt.Sprintf("context-%d-%d", writer, i)); err != nil {
+					t.Errorf("Failed to record switch: %v", err)
+				}
+			}
+		}(writer)
+	}
+	wg.Wait()
+
+	entries, err := GetHistory()
+	if err != nil {
+		t.Fatalf("Failed to read history: %v", err)
+	}
+	if len(entries) != maxHistoryEntries {
+		t.Errorf("Expected %d history entries, but got %d", maxHistoryEntries, len(entries))
+	}
+}
+
+func TestGetHistorySkipsBrokenLines(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	historyPath, err := getHistoryPath()
+	if err != nil {
+		t.Fatalf("Failed to get history path: %v", err)
+	}
+	if err := CreateDirectoryIfNotExists(filepath.Dir(historyPath)); err != nil {
+		t.Fatalf("Failed to create state directory: %v", err)
+	}
+	content := "{\"time\":\"2026-01-02T03:04:05Z\",\"context\":\"a\"}\nnot json\n{\"time\":\"2026-01-02T03:04:06Z\",\"context\":\"b\",\"previous\":\"a\"}\n"
+	if err := os.WriteFile(historyPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write history: %v", err)
+	}
+
+	entries, err := GetHistory()
+	if err != nil {
+		t.Fatalf("Failed to read history: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected 2 history entries, but got %d", len(entries))
+	}
+}
@@ -0,0 +1,369 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Ways to resolve an imported entry whose name is already taken
+const (
+	ImportRename    = "rename"
+	ImportOverwrite = "overwrite"
+	ImportSkip      = "skip"
+	ImportAsk       = "ask"
+)
+
+var ImportStrategies = []string{ImportRename, ImportOverwrite, ImportSkip, ImportAsk}
+
+type ImportOptions struct {
+	Strategy string
+
+	// Ask chooses rename, overwrite or skip for one conflict when Strategy
+	// is ImportAsk
+	Ask func(kind, name string) (string, error)
+}
+
+// ImportChange records what happened to one imported entry. Action is added,
+// renamed, overwritten, skipped or unchanged. NewName is set when the entry
+// was renamed, or is unchanged because an earlier import renamed it.
+type ImportChange struct {
+	Kind    string
+	Name    string
+	Action  string
+	NewName string
+}
+
+// importOperation is a change to one kubeconfig file
+type importOperation struct {
+	path   string
+	modify func(doc *kubeConfigDocument) error
+}
+
+func ImportKubeConfig(path string, data []byte, options ImportOptions) ([]ImportChange, error) {
+	return DefaultClient().Import(context.Background(), path, data, options)
+}
+
+// Import merges the clusters, users and contexts of a kubeconfig into the
+// files of the store. New entries are added to the primary file,
+// overwritten entries are replaced where they are defined. Identical entries
+// are left alone. The content may be base64 encoded, and relative file paths
+// in it are resolved against the directory of path ("-" for stdin resolves
+// against the working directory).
+//
+// Contexts that use a renamed cluster or user are updated to the new name. A
+// skipped cluster or user is not imported, contexts keep using the existing
+// entry of that name.
+func (c *Client) Import(ctx context.Context, path string, data []byte, options ImportOptions) ([]ImportChange, error) {
+	strategyKnown := false
+	for _, strategy := range ImportStrategies {
+		strategyKnown = strategyKnown || strategy == options.Strategy
+	}
+	if !strategyKnown {
+		return nil, fmt.Errorf("unknown strategy '%s', use %s", options.Strategy, strings.Join(ImportStrategies, ", "))
+	}
+
+	name := path
+	if path == "-" {
+		name = "stdin"
+	}
+	absolute, err := filepath.Abs(name)
+	if err != nil {
+		return nil, err
+	}
+	imported, err := parseKubeConfigDocument(absolute, decodeImportData(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(imported.Config.Contexts)+len(imported.Config.Clusters)+len(imported.Config.Users) == 0 {
+		return nil, fmt.Errorf("no contexts, clusters or users found in %s", name)
+	}
+
+	files, err := c.loadExistingFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	merged := mergeKubeConfigs(files)
+	// Entries go to the first file, but never to the file of a session,
+	// they would be lost when the session ends
+	var target string
+	for _, file := range files {
+		if file.Path != c.session() {
+			target = file.Path
+			break
+		}
+	}
+	exists := target != ""
+	if !exists {
+		if target, err = c.primaryPath(); err != nil {
+			return nil, err
+		}
+	}
+
+	taken := map[string]map[string]bool{"cluster": {}, "user": {}, "context": {}}
+	renamed := map[string]map[string]string{"cluster": {}, "user": {}}
+	var changes []ImportChange
+	var operations []importOperation
+
+	for _, listKey := range []string{"clusters", "users", "contexts"} {
+		kind := strings.TrimSuffix(listKey, "s")
+		_, list := mappingValue(imported.mapping(), listKey)
+		if list == nil || list.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		for _, node := range list.Content {
+			_, nameNode := mappingValue(node, "name")
+			if nameNode == nil || nameNode.Value == "" || taken[kind][nameNode.Value] {
+				continue
+			}
+			itemName := nameNode.Value
+			taken[kind][itemName] = true
+
+			item := deepCopyNode(node)
+			stripComments(item)
+			_, info := mappingValue(item, kind)
+			if err := exportFileFields(info, fileFields[kind], filepath.Dir(absolute), false); err != nil {
+				return nil, err
+			}
+
+			// The details of the entry as they will be written, to compare
+			// with existing entries regardless of their name
+			var importedEntry interface{}
+			var findExisting func(name string) (interface{}, bool)
+			switch kind {
+			case "cluster":
+				importedEntry = imported.Config.FindCluster(itemName).Cluster
+				findExisting = func(name string) (interface{}, bool) {
+					if cluster := merged.FindCluster(name); cluster != nil {
+						return cluster.Cluster, true
+					}
+					return nil, false
+				}
+			case "user":
+				importedEntry = imported.Config.FindUser(itemName).User
+				findExisting = func(name string) (interface{}, bool) {
+					if user := merged.FindUser(name); user != nil {
+						return user.User, true
+					}
+					return nil, false
+				}
+			case "context":
+				context := imported.Config.FindContext(itemName).Context
+				for _, reference := range []struct {
+					kind  string
+					value *string
+				}{{"cluster", &context.Cluster}, {"user", &context.User}} {
+					if newName, ok := renamed[reference.kind][*reference.value]; ok {
+						*reference.value = newName
+						if err := setNodeValue(info, reference.kind, newName); err != nil {
+							return nil, err
+						}
+					}
+				}
+				importedEntry = context
+				findExisting = func(name string) (interface{}, bool) {
+					if context := merged.FindContext(name); context != nil {
+						return context.Context, true
+					}
+					return nil, false
+				}
+			}
+			sameAs := func(name string) bool {
+				existing, ok := findExisting(name)
+				return ok && reflect.DeepEqual(existing, importedEntry)
+			}
+			_, exists := findExisting(itemName)
+
+			change := ImportChange{Kind: kind, Name: itemName, Action: "added"}
+			if exists {
+				if sameAs(itemName) {
+					change.Action = "unchanged"
+					changes = append(changes, change)
+					continue
+				}
+
+				strategy := options.Strategy
+				if strategy == ImportAsk {
+					if options.Ask == nil {
+						return nil, fmt.Errorf("%s '%s' already exists and cannot be asked about", kind, itemName)
+					}
+					if strategy, err = options.Ask(kind, itemName); err != nil {
+						return nil, err
+					}
+				}
+
+				switch strategy {
+				case ImportSkip:
+					change.Action = "skipped"
+					changes = append(changes, change)
+					continue
+				case ImportOverwrite:
+					change.Action = "overwritten"
+					owner := definingFile(files, listKey, itemName)
+					operations = append(operations, importOperation{owner, func(doc *kubeConfigDocument) error {
+						return doc.ReplaceItem(listKey, itemName, item)
+					}})
+					changes = append(changes, change)
+					continue
+				case ImportRename:
+					change.Action = "renamed"
+					change.NewName = uniqueName(itemName, func(candidate string) bool {
+						return (definingFile(files, listKey, candidate) != "" && !sameAs(candidate)) || taken[kind][candidate]
+					})
+					taken[kind][change.NewName] = true
+					if kind != "context" {
+						renamed[kind][itemName] = change.NewName
+					}
+					// Imported before under this name
+					if definingFile(files, listKey, change.NewName) != "" {
+						change.Action = "unchanged"
+						changes = append(changes, change)
+						continue
+					}
+					if err := setNodeValue(item, "name", change.NewName); err != nil {
+						return nil, err
+					}
+				default:
+					return nil, fmt.Errorf("unknown strategy '%s' for %s '%s'", strategy, kind, itemName)
+				}
+			}
+
+			operations = append(operations, importOperation{target, func(doc *kubeConfigDocument) error {
+				return doc.AppendItem(listKey, item)
+			}})
+			changes = append(changes, change)
+		}
+	}
+
+	// Without a current context, use the one of the imported file
+	if merged.CurrentContext == "" && imported.Config.CurrentContext != "" {
+		for _, change := range changes {
+			if change.Kind != "context" || change.Name != imported.Config.CurrentContext || change.Action == "skipped" {
+				continue
+			}
+			currentContext := change.Name
+			if change.NewName != "" {
+				currentContext = change.NewName
+			}
+			operations = append(operations, importOperation{target, func(doc *kubeConfigDocument) error {
+				return doc.SetCurrentContext(currentContext)
+			}})
+		}
+	}
+
+	if len(operations) == 0 {
+		return changes, nil
+	}
+	if !exists {
+		if err := c.store.Save(ctx, target, []byte("apiVersion: v1\nkind: Config\n")); err != nil {
+			return nil, fmt.Errorf("failed to create kubeconfig file: %w", err)
+		}
+	}
+
+	// One locked update per file, with the operations in their original order
+	var paths []string
+	byPath := make(map[string][]importOperation)
+	for _, operation := range operations {
+		if _, ok := byPath[operation.path]; !ok {
+			paths = append(paths, operation.path)
+		}
+		byPath[operation.path] = append(byPath[operation.path], operation)
+	}
+	for _, path := range paths {
+		err := c.update(ctx, path, func(doc *kubeConfigDocument) error {
+			for _, operation := range byPath[path] {
+				if err := operation.modify(doc); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
+
+// decodeImportData decodes base64 content, as kubeconfigs are often stored in
+// CI secrets. YAML never decodes as base64, it contains ":" and spaces.
+func decodeImportData(data []byte) []byte {
+	compact := strings.Join(strings.Fields(string(data)), "")
+	if compact == "" {
+		return data
+	}
+	decoded, err := base64.StdEncoding.DecodeString(compact)
+	if err != nil {
+		return data
+	}
+	return decoded
+}
+
+// loadExistingFiles is loadFiles, except that having no kubeconfig file at
+// all is not an error
+func (c *Client) loadExistingFiles(ctx context.Context) ([]*kubeConfigDocument, error) {
+	files, err := c.loadFiles(ctx)
+	if errors.Is(err, ErrKubeconfigNotFound) {
+		return nil, nil
+	}
+	return files, err
+}
+
+// primaryPath returns the file new entries go to when the store has no files
+// yet, other than the file of a session. Only a FileStore knows where that is.
+func (c *Client) primaryPath() (string, error) {
+	if store, ok := c.store.(*FileStore); ok {
+		paths, err := store.Paths()
+		if err != nil {
+			return "", err
+		}
+		for _, path := range paths {
+			if path != c.session() {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: the store has no file to import into", ErrKubeconfigNotFound)
+}
+
+// definingFile returns the path of the first file that defines name in a list
+func definingFile(files []*kubeConfigDocument, listKey, name string) string {
+	for _, file := range files {
+		if file.namedItem(listKey, name) != nil {
+			return file.Path
+		}
+	}
+	return ""
+}
+
+// uniqueName appends -2, -3 and so on to name until it is no longer taken
+func uniqueName(name string, taken func(string) bool) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// setNodeValue sets the value of a scalar key in a mapping node that is not
+// part of a document yet
+func setNodeValue(mapping *yaml.Node, key, value string) error {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return fmt.Errorf("cannot set %s, the entry is not a mapping", key)
+	}
+	if _, valueNode := mappingValue(mapping, key); valueNode != nil {
+		valueNode.Value = value
+		return nil
+	}
+	mapping.Content = append(mapping.Content, scalarNode(key), scalarNode(value))
+	return nil
+}
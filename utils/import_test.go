@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ryo-nabata/kubec/internal/kubetest"
+)
+
+// The new cluster conflicts with prod-cluster, dev-user is identical to the
+// existing one
+const importKubeConfig = `clusters:
+- cluster:
+    server: https://new-prod.example.com
+    certificate-authority: ca.crt
+  name: prod-cluster
+- cluster:
+    server: https://staging.example.com
+  name: staging-cluster
+contexts:
+- context:
+    cluster: prod-cluster
+    user: dev-user
+  name: new-prod
+- context:
+    cluster: staging-cluster
+    user: dev-user
+  name: staging
+current-context: staging
+users:
+- name: dev-user
+  user:
+    token: dev-token
+`
+
+func formatChanges(changes []ImportChange) string {
+	var lines []string
+	for _, change := range changes {
+		line := fmt.Sprintf("%s %s %s", change.Action, change.Kind, change.Name)
+		if change.NewName != "" {
+			line += " as " + change.NewName
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestImportKubeConfigRename(t *testing.T) {
+	configPath := kubetest.WriteKubeConfig(t, manageKubeConfig)
+	// Only the directory of the imported file matters, the content is passed
+	importPath := filepath.Join(t.TempDir(), "new.kubeconfig")
+
+	changes, err := ImportKubeConfig(importPath, []byte(importKubeConfig), ImportOptions{Strategy: ImportRename})
+	if err != nil {
+		t.Fatalf("Failed to import kubeconfig: %v", err)
+	}
+
+	expected := `renamed cluster prod-cluster as prod-cluster-2
+added cluster staging-cluster
+unchanged user dev-user
+added context new-prod
+added context staging`
+	if summary := formatChanges(changes); summary != expected {
+		t.Errorf("Expected changes:\n%s\nbut got:\n%s", expected, summary)
+	}
+
+	// The existing content is kept as it was, including comments
+	data := readTestFile(t, configPath)
+	if !strings.HasPrefix(data, manageKubeConfig[:strings.Index(manageKubeConfig, "contexts:")]) || !strings.Contains(data, "# development context") {
+		t.Errorf("Expected existing entries to be untouched, but got:\n%s", data)
+	}
+
+	config, err := DefaultClient().Config(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to load kubeconfig: %v", err)
+	}
+	if config.CurrentContext != "prod" {
+		t.Errorf("Expected current context to stay prod, but got %s", config.CurrentContext)
+	}
+	if cluster := config.FindCluster("prod-cluster"); cluster.Cluster.Server != "https://prod.example.com" {
+		t.Errorf("Expected prod-cluster to be unchanged, but got %s", cluster.Cluster.Server)
+	}
+	renamed := config.FindCluster("prod-cluster-2")
+	if renamed == nil || renamed.Cluster.CertificateAuthority != filepath.Join(filepath.Dir(importPath), "ca.crt") {
+		t.Errorf("Expected prod-cluster-2 with an absolute CA path, but got %+v", renamed)
+	}
+	if context := config.FindContext("new-prod"); context == nil || context.Context.Cluster != "prod-cluster-2" {
+		t.Errorf("Expected new-prod to use the renamed cluster, but got %+v", context)
+	}
+
+	// Importing again finds the renamed copy instead of adding another one
+	changes, err = ImportKubeConfig(importPath, []byte(importKubeConfig), ImportOptions{Strategy: ImportRename})
+	if err != nil {
+		t.Fatalf("Failed to import kubeconfig: %v", err)
+	}
+	expected = `unchanged cluster prod-cluster as prod-cluster-2
+unchanged cluster staging-cluster
+unchanged user dev-user
+unchanged context new-prod
+unchanged context staging`
+	if summary := formatChanges(changes); summary != expected {
+		t.Errorf("Expected changes:\n%s\nbut got:\n%s", expected, summary)
+	}
+}
+
+func TestImportKubeConfigOverwriteAndSkip(t *testing.T) {
+	kubetest.WriteKubeConfig(t, manageKubeConfig)
+	importPath := filepath.Join(t.TempDir(), "new.kubeconfig")
+
+	changes, err := ImportKubeConfig(importPath, []byte(importKubeConfig), ImportOptions{Strategy: ImportOverwrite})
+	if err != nil {
+		t.Fatalf("Failed to import kubeconfig: %v", err)
+	}
+	if !strings.Contains(formatChanges(changes), "overwritten cluster prod-cluster") {
+		t.Errorf("Expected prod-cluster to be overwritten, but got:\n%s", formatChanges(changes))
+	}
+	config, err := DefaultClient().Config(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to load kubeconfig: %v", err)
+	}
+	if cluster := config.FindCluster("prod-cluster"); cluster.Cluster.Server != "https://new-prod.example.com" {
+		t.Errorf("Expected prod-cluster to be overwritten, but got %s", cluster.Cluster.Server)
+	}
+
+	// Importing the same file again changes nothing
+	changes, err = ImportKubeConfig(importPath, []byte(importKubeConfig), ImportOptions{Strategy: ImportSkip})
+	if err != nil {
+		t.Fatalf("Failed to import kubeconfig: %v", err)
+	}
+	for _, change := range changes {
+		if change.Action != "unchanged" {
+			t.Errorf("Expected every entry to be unchanged, but got:\n%s", formatChanges(changes))
+			break
+		}
+	}
+}
+
+func TestImportKubeConfigAskAndBase64(t *testing.T) {
+	kubetest.WriteKubeConfig(t, manageKubeConfig)
+
+	var asked []string
+	encoded := base64.StdEncoding.EncodeToString([]byte(importKubeConfig))
+	changes, err := ImportKubeConfig("-", []byte(encoded+"\n"), ImportOptions{
+		Strategy: ImportAsk,
+		Ask: func(kind, name string) (string, error) {
+			asked = append(asked, kind+" "+name)
+			return ImportSkip, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to import kubeconfig: %v", err)
+	}
+	if strings.Join(asked, ",") != "cluster prod-cluster" {
+		t.Errorf("Expected to be asked about prod-cluster only, but was asked about %v", asked)
+	}
+	if !strings.Contains(formatChanges(changes), "skipped cluster prod-cluster") {
+		t.Errorf("Expected prod-cluster to be skipped, but got:\n%s", formatChanges(changes))
+	}
+}
+
+func TestImportKubeConfigIntoNewFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "kube", "config")
+	t.Setenv("KUBECONFIG", configPath)
+	importPath := filepath.Join(t.TempDir(), "new.kubeconfig")
+
+	if _, err := ImportKubeConfig(importPath, []byte(importKubeConfig), ImportOptions{Strategy: ImportRename}); err != nil {
+		t.Fatalf("Failed to import kubeconfig: %v", err)
+	}
+
+	info, err := os.Stat(configPath)
+	if err != nil {
+		t.Fatalf("Expected the kubeconfig file to be created: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected permissions 0600, but got %o", info.Mode().Perm())
+	}
+	config, err := DefaultClient().Config(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to load kubeconfig: %v", err)
+	}
+	if config.CurrentContext != "staging" || len(config.Contexts) != 2 {
+		t.Errorf("Expected both contexts with staging as current context, but got %+v", config)
+	}
+}
+
+func TestAppendItemToFlowList(t *testing.T) {
+	doc, err := parseKubeConfigDocument("config", []byte("users: []\ncurrent-context: a\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	imported, err := parseKubeConfigDocument("import", []byte(importKubeConfig))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	if err := doc.AppendItem("users", imported.namedItem("users", "dev-user")); err != nil {
+		t.Fatalf("Failed to append user: %v", err)
+	}
+	if doc.Config.FindUser("dev-user") == nil || doc.Config.CurrentContext != "a" {
+		t.Errorf("Expected dev-user to be added, but got:\n%s", doc.Data)
+	}
+}
+
+func TestImportKubeConfigInSession(t *testing.T) {
+	configPath := kubetest.WriteKubeConfig(t, manageKubeConfig)
+	session := startTestSession(t, "dev")
+	importPath := filepath.Join(t.TempDir(), "new.kubeconfig")
+
+	if _, err := ImportKubeConfig(importPath, []byte(importKubeConfig), ImportOptions{Strategy: ImportRename}); err != nil {
+		t.Fatalf("Failed to import kubeconfig: %v", err)
+	}
+
+	// The entries outlive the session
+	if data := readTestFile(t, configPath); !strings.Contains(data, "name: new-prod") {
+		t.Errorf("Expected new-prod in %s, but got:\n%s", configPath, data)
+	}
+	if data := readTestFile(t, session.Path); strings.Contains(data, "new-prod") {
+		t.Errorf("Expected the session file to be untouched, but got:\n%s", data)
+	}
+}
@@ -1,14 +1,11 @@
 package utils
 
 import (
+	"context"
+	"crypto/sha256"
 	"fmt"
-	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
-	"sort"
-
-	"gopkg.in/yaml.v3"
 )
 
 type KubeConfig struct {
@@ -27,9 +24,15 @@ type Context struct {
 }
 
 type ContextInfo struct {
-	Cluster   string `yaml:"cluster"`
-	User      string `yaml:"user"`
-	Namespace string `yaml:"namespace,omitempty"`
+	Cluster    string           `yaml:"cluster"`
+	User       string           `yaml:"user"`
+	Namespace  string           `yaml:"namespace,omitempty"`
+	Extensions []NamedExtension `yaml:"extensions,omitempty"`
+}
+
+type NamedExtension struct {
+	Name      string                 `yaml:"name"`
+	Extension map[string]interface{} `yaml:"extension"`
 }
 
 type Cluster struct {
@@ -42,6 +45,7 @@ type ClusterInfo struct {
 	CertificateAuthority     string `yaml:"certificate-authority,omitempty"`
 	CertificateAuthorityData string `yaml:"certificate-authority-data,omitempty"`
 	InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify,omitempty"`
+	TLSServerName            string `yaml:"tls-server-name,omitempty"`
 }
 
 type User struct {
@@ -51,6 +55,7 @@ type User struct {
 
 type UserInfo struct {
 	Token                string                 `yaml:"token,omitempty"`
+	TokenFile            string                 `yaml:"tokenFile,omitempty"`
 	ClientCertificate    string                 `yaml:"client-certificate,omitempty"`
 	ClientKey            string                 `yaml:"client-key,omitempty"`
 	ClientCertificateData string                `yaml:"client-certificate-data,omitempty"`
@@ -75,97 +80,244 @@ type ExecEnvVar struct {
 	Value string `yaml:"value"`
 }
 
-func GetKubeConfigPath() string {
-	// Use KUBECONFIG environment variable if set
-	if kubeconfigPath := os.Getenv("KUBECONFIG"); kubeconfigPath != "" {
-		return kubeconfigPath
+func (c *KubeConfig) FindContext(name string) *Context {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			return &c.Contexts[i]
+		}
 	}
-	
-	// Use default path
-	homeDir := GetHomeDir()
-	return filepath.Join(homeDir, ".kube", "config")
+	return nil
 }
 
-func loadKubeConfig() (*KubeConfig, error) {
-	configPath := GetKubeConfigPath()
-	
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("kubeconfig file not found: %s", configPath)
+func (c *KubeConfig) FindCluster(name string) *Cluster {
+	for i := range c.Clusters {
+		if c.Clusters[i].Name == name {
+			return &c.Clusters[i]
+		}
 	}
-	
-	data, err := ioutil.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read kubeconfig file: %v", err)
+	return nil
+}
+
+func (c *KubeConfig) FindUser(name string) *User {
+	for i := range c.Users {
+		if c.Users[i].Name == name {
+			return &c.Users[i]
+		}
 	}
-	
-	var config KubeConfig
-	err = yaml.Unmarshal(data, &config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse kubeconfig file: %v", err)
+	return nil
+}
+
+// resolveLocalPaths makes relative file references absolute against the
+// directory of the kubeconfig file that holds them, as kubectl does. Only the
+// parsed model changes; files are never rewritten from it.
+func resolveLocalPaths(config *KubeConfig, dir string) {
+	resolve := func(path *string) {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+	for i := range config.Clusters {
+		resolve(&config.Clusters[i].Cluster.CertificateAuthority)
+	}
+	for i := range config.Users {
+		resolve(&config.Users[i].User.ClientCertificate)
+		resolve(&config.Users[i].User.ClientKey)
+		resolve(&config.Users[i].User.TokenFile)
 	}
-	
-	return &config, nil
 }
 
-func GetContexts() []string {
-	config, err := loadKubeConfig()
+func GetKubeConfigPath() (string, error) {
+	paths, err := GetKubeConfigPaths()
 	if err != nil {
-		log.Fatalf("failed to load kubeconfig: %v", err)
+		return "", err
 	}
 	
-	var contexts []string
-	for _, context := range config.Contexts {
-		contexts = append(contexts, context.Name)
+	// The first entry is the primary kubeconfig file
+	return paths[0], nil
+}
+
+func GetKubeConfigPaths() ([]string, error) {
+	// Use KUBECONFIG environment variable if set, it may list several files
+	if kubeconfigPath := os.Getenv("KUBECONFIG"); kubeconfigPath != "" {
+		var paths []string
+		seen := make(map[string]bool)
+		for _, path := range filepath.SplitList(kubeconfigPath) {
+			if path == "" || seen[path] {
+				continue
+			}
+			seen[path] = true
+			paths = append(paths, path)
+		}
+		if len(paths) > 0 {
+			return paths, nil
+		}
 	}
 	
-	// Sort in alphabetical order
-	sort.Strings(contexts)
-	
-	return contexts
+	// Use default path
+	homeDir, err := GetHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return []string{filepath.Join(homeDir, ".kube", "config")}, nil
 }
 
-func GetCurrentContext() string {
-	config, err := loadKubeConfig()
+func parseStoreFile(file StoreFile) (*kubeConfigDocument, error) {
+	doc, err := parseKubeConfigDocument(file.Path, file.Data)
 	if err != nil {
-		log.Fatalf("failed to load kubeconfig: %v", err)
+		return nil, err
 	}
+	doc.Checksum = sha256.Sum256(file.Data)
 	
-	return config.CurrentContext
+	return doc, nil
 }
 
-func SetCurrentContext(contextName string) error {
-	config, err := loadKubeConfig()
+const maxUpdateAttempts = 3
+
+// updateStoreFile runs the load, modify and save cycle for one file while
+// holding its lock. Writers that do not take the lock are detected by
+// comparing the content hash before saving; the edit is then retried on the
+// new content, and abandoned after maxUpdateAttempts.
+func updateStoreFile(ctx context.Context, store Store, configPath string, modify func(doc *kubeConfigDocument) error) error {
+	unlock, err := store.Lock(ctx, configPath)
 	if err != nil {
-		return fmt.Errorf("failed to load kubeconfig: %v", err)
+		return err
 	}
+	defer unlock()
 	
-	// Check if the specified context exists
-	found := false
-	for _, context := range config.Contexts {
-		if context.Name == contextName {
-			found = true
-			break
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		data, err := store.Read(ctx, configPath)
+		if err != nil {
+			return err
+		}
+		doc, err := parseStoreFile(StoreFile{Path: configPath, Data: data})
+		if err != nil {
+			return err
+		}
+		
+		err = modify(doc)
+		if err != nil {
+			return err
+		}
+		
+		current, err := store.Read(ctx, configPath)
+		if err != nil {
+			return err
+		}
+		if sha256.Sum256(current) != doc.Checksum {
+			continue
 		}
+		
+		return store.Save(ctx, configPath, doc.Data)
 	}
 	
-	if !found {
-		return fmt.Errorf("context '%s' not found", contextName)
+	return fmt.Errorf("kubeconfig file %s keeps changing while kubec updates it, aborting to avoid overwriting another writer", configPath)
+}
+
+func loadStoreFiles(ctx context.Context, store Store) ([]*kubeConfigDocument, error) {
+	stored, err := store.Load(ctx)
+	if err != nil {
+		return nil, err
 	}
 	
-	// Update current-context
-	config.CurrentContext = contextName
-	
-	// Write back to file
-	data, err := yaml.Marshal(config)
-	if err != nil {
-		return fmt.Errorf("failed to prepare kubeconfig write: %v", err)
+	files := make([]*kubeConfigDocument, len(stored))
+	for i, file := range stored {
+		files[i], err = parseStoreFile(file)
+		if err != nil {
+			return nil, err
+		}
 	}
 	
-	configPath := GetKubeConfigPath()
-	err = ioutil.WriteFile(configPath, data, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to write kubeconfig: %v", err)
+	return files, nil
+}
+
+// mergeKubeConfigs merges kubeconfig files in order, the first definition of
+// each context, cluster, user and current-context wins.
+func mergeKubeConfigs(files []*kubeConfigDocument) *KubeConfig {
+	merged := &KubeConfig{}
+	seenContexts := make(map[string]bool)
+	seenClusters := make(map[string]bool)
+	seenUsers := make(map[string]bool)
+	
+	for _, file := range files {
+		config := file.Config
+		if merged.ApiVersion == "" {
+			merged.ApiVersion = config.ApiVersion
+		}
+		if merged.Kind == "" {
+			merged.Kind = config.Kind
+		}
+		if merged.CurrentContext == "" {
+			merged.CurrentContext = config.CurrentContext
+		}
+		if merged.Preferences == nil {
+			merged.Preferences = config.Preferences
+		}
+		
+		for _, context := range config.Contexts {
+			if !seenContexts[context.Name] {
+				seenContexts[context.Name] = true
+				merged.Contexts = append(merged.Contexts, context)
+			}
+		}
+		for _, cluster := range config.Clusters {
+			if !seenClusters[cluster.Name] {
+				seenClusters[cluster.Name] = true
+				merged.Clusters = append(merged.Clusters, cluster)
+			}
+		}
+		for _, user := range config.Users {
+			if !seenUsers[user.Name] {
+				seenUsers[user.Name] = true
+				merged.Users = append(merged.Users, user)
+			}
+		}
 	}
 	
-	return nil
-}
\ No newline at end of file
+	return merged
+}
+
+// currentContextFile returns the file that owns current-context: the first
+// file that sets it, or the first file in the list.
+func currentContextFile(files []*kubeConfigDocument) *kubeConfigDocument {
+	for _, file := range files {
+		if file.Config.CurrentContext != "" {
+			return file
+		}
+	}
+	return files[0]
+}
+
+func GetContexts() ([]string, error) {
+	return DefaultClient().Contexts(context.Background())
+}
+
+func GetCurrentContext() (string, error) {
+	return DefaultClient().CurrentContext(context.Background())
+}
+
+func GetCurrentNamespace() (string, error) {
+	return DefaultClient().CurrentNamespace(context.Background())
+}
+
+// SetNamespace changes the namespace of the current context in the file that
+// defines the context
+func SetNamespace(namespace string) error {
+	return DefaultClient().SetNamespace(context.Background(), namespace)
+}
+
+func SetCurrentContext(contextName string) error {
+	return DefaultClient().UseContext(context.Background(), contextName)
+}
+
+func hasSecrets(config *KubeConfig) bool {
+	for _, user := range config.Users {
+		info := user.User
+		if info.Token != "" || info.ClientKeyData != "" || info.Password != "" || len(info.AuthProvider) > 0 {
+			return true
+		}
+		if info.Exec != nil && len(info.Exec.Env) > 0 {
+			return true
+		}
+	}
+	return false
+}
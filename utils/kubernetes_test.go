@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"github.com/ryo-nabata/kubec/internal/kubetest"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,8 +23,12 @@ func TestGetKubeConfigPath(t *testing.T) {
 
 	// Test default path
 	os.Unsetenv("KUBECONFIG")
-	expectedPath := filepath.Join(GetHomeDir(), ".kube", "config")
-	actualPath := GetKubeConfigPath()
+	homeDir, _ := GetHomeDir()
+	expectedPath := filepath.Join(homeDir, ".kube", "config")
+	actualPath, err := GetKubeConfigPath()
+	if err != nil {
+		t.Fatalf("Failed to get kubeconfig path: %v", err)
+	}
 	if actualPath != expectedPath {
 		t.Errorf("Expected %s, but got %s", expectedPath, actualPath)
 	}
@@ -29,7 +36,7 @@ func TestGetKubeConfigPath(t *testing.T) {
 	// Test when environment variable is set
 	customPath := "/custom/path/config"
 	os.Setenv("KUBECONFIG", customPath)
-	actualPath = GetKubeConfigPath()
+	actualPath, _ = GetKubeConfigPath()
 	if actualPath != customPath {
 		t.Errorf("Expected %s, but got %s", customPath, actualPath)
 	}
@@ -88,7 +95,7 @@ func TestLoadKubeConfig(t *testing.T) {
 	defer os.Unsetenv("KUBECONFIG")
 
 	// Test loadKubeConfig
-	config, err := loadKubeConfig()
+	config, err := DefaultClient().Config(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to load kubeconfig: %v", err)
 	}
@@ -135,7 +142,10 @@ func TestGetContexts(t *testing.T) {
 	os.Setenv("KUBECONFIG", configPath)
 	defer os.Unsetenv("KUBECONFIG")
 
-	contexts := GetContexts()
+	contexts, err := GetContexts()
+	if err != nil {
+		t.Fatalf("Failed to get contexts: %v", err)
+	}
 	
 	// Check if sorted in alphabetical order
 	expected := []string{"context-a", "context-b", "context-c"}
@@ -177,7 +187,10 @@ func TestGetCurrentContext(t *testing.T) {
 	os.Setenv("KUBECONFIG", configPath)
 	defer os.Unsetenv("KUBECONFIG")
 
-	currentContext := GetCurrentContext()
+	currentContext, err := GetCurrentContext()
+	if err != nil {
+		t.Fatalf("Failed to get current context: %v", err)
+	}
 	if currentContext != "current-test-context" {
 		t.Errorf("Expected current context 'current-test-context', but got '%s'", currentContext)
 	}
@@ -218,7 +231,10 @@ func TestSetCurrentContext(t *testing.T) {
 	}
 
 	// Check if changes are reflected
-	currentContext := GetCurrentContext()
+	currentContext, err := GetCurrentContext()
+	if err != nil {
+		t.Fatalf("Failed to get current context: %v", err)
+	}
 	if currentContext != "new-context" {
 		t.Errorf("Expected current context 'new-context', but got '%s'", currentContext)
 	}
@@ -228,4 +244,182 @@ func TestSetCurrentContext(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error when setting non-existent context, but got none")
 	}
-}
\ No newline at end of file
+}
+
+// marshalTestKubeConfig returns config as the content of a kubeconfig file
+func marshalTestKubeConfig(t *testing.T, config KubeConfig) string {
+	t.Helper()
+	data, err := yaml.Marshal(&config)
+	if err != nil {
+		t.Fatalf("Failed to marshal test config: %v", err)
+	}
+	return string(data)
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestGetKubeConfigPathsMultipleFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	first := filepath.Join(tempDir, "a")
+	second := filepath.Join(tempDir, "b")
+
+	// Empty entries and duplicates are dropped
+	t.Setenv("KUBECONFIG", first+string(filepath.ListSeparator)+string(filepath.ListSeparator)+second+string(filepath.ListSeparator)+first)
+
+	paths, err := GetKubeConfigPaths()
+	if err != nil {
+		t.Fatalf("Failed to get kubeconfig paths: %v", err)
+	}
+	if len(paths) != 2 || paths[0] != first || paths[1] != second {
+		t.Errorf("Expected [%s %s], but got %v", first, second, paths)
+	}
+
+	if path, _ := GetKubeConfigPath(); path != first {
+		t.Errorf("Expected primary path %s, but got %s", first, path)
+	}
+}
+
+func TestMergeKubeConfigFirstDefinitionWins(t *testing.T) {
+	paths := kubetest.WriteKubeConfigs(t, marshalTestKubeConfig(t, KubeConfig{
+		Contexts: []Context{
+			{Name: "shared", Context: ContextInfo{Cluster: "cluster-a"}},
+			{Name: "only-a"},
+		},
+	}), marshalTestKubeConfig(t, KubeConfig{
+		CurrentContext: "only-b",
+		Contexts: []Context{
+			{Name: "shared", Context: ContextInfo{Cluster: "cluster-b"}},
+			{Name: "only-b"},
+		},
+	}))
+
+	// Missing files are skipped
+	missing := filepath.Join(filepath.Dir(paths[0]), "missing")
+	t.Setenv("KUBECONFIG", strings.Join(append([]string{missing}, paths...), string(filepath.ListSeparator)))
+
+	config, err := DefaultClient().Config(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to load kubeconfig: %v", err)
+	}
+
+	if config.CurrentContext != "only-b" {
+		t.Errorf("Expected current-context 'only-b', but got '%s'", config.CurrentContext)
+	}
+
+	if len(config.Contexts) != 3 {
+		t.Fatalf("Expected 3 contexts, but got %d", len(config.Contexts))
+	}
+
+	if config.Contexts[0].Context.Cluster != "cluster-a" {
+		t.Errorf("Expected first definition of 'shared' to win, but got cluster '%s'", config.Contexts[0].Context.Cluster)
+	}
+
+	contexts, err := GetContexts()
+	if err != nil {
+		t.Fatalf("Failed to get contexts: %v", err)
+	}
+	expected := []string{"only-a", "only-b", "shared"}
+	if strings.Join(contexts, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected contexts %v, but got %v", expected, contexts)
+	}
+}
+
+func TestSetCurrentContextMultipleFiles(t *testing.T) {
+	paths := kubetest.WriteKubeConfigs(t, marshalTestKubeConfig(t, KubeConfig{
+		Contexts: []Context{{Name: "context-a"}},
+	}), marshalTestKubeConfig(t, KubeConfig{
+		CurrentContext: "context-b",
+		Contexts:       []Context{{Name: "context-b"}},
+	}))
+	first, second := paths[0], paths[1]
+	firstBefore := readTestFile(t, first)
+
+	// current-context is written to the file that already sets it
+	if err := SetCurrentContext("context-a"); err != nil {
+		t.Fatalf("Failed to set current context: %v", err)
+	}
+
+	if readTestFile(t, first) != firstBefore {
+		t.Errorf("Expected %s to be untouched", first)
+	}
+
+	config, err := NewClient(NewFileStore(second)).Config(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to load second file: %v", err)
+	}
+	if config.CurrentContext != "context-a" {
+		t.Errorf("Expected current-context 'context-a' in %s, but got '%s'", second, config.CurrentContext)
+	}
+
+	// Without any current-context the first file in the list is used
+	if err := os.WriteFile(second, []byte(marshalTestKubeConfig(t, KubeConfig{
+		Contexts: []Context{{Name: "context-b"}},
+	})), 0600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	if err := SetCurrentContext("context-b"); err != nil {
+		t.Fatalf("Failed to set current context: %v", err)
+	}
+
+	config, err = NewClient(NewFileStore(first)).Config(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to load first file: %v", err)
+	}
+	if config.CurrentContext != "context-b" {
+		t.Errorf("Expected current-context 'context-b' in %s, but got '%s'", first, config.CurrentContext)
+	}
+}
+
+func TestSetCurrentContextRestrictsSecretPermissions(t *testing.T) {
+	configPath := kubetest.WriteKubeConfig(t, marshalTestKubeConfig(t, KubeConfig{
+		Contexts: []Context{{Name: "secret-context"}},
+		Users:    []User{{Name: "admin", User: UserInfo{Token: "secret-token"}}},
+	}))
+	if err := os.Chmod(configPath, 0644); err != nil {
+		t.Fatalf("Failed to chmod test config: %v", err)
+	}
+
+	if err := SetCurrentContext("secret-context"); err != nil {
+		t.Fatalf("Failed to set current context: %v", err)
+	}
+
+	info, err := os.Stat(configPath)
+	if err != nil {
+		t.Fatalf("Failed to stat test config: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600 for a kubeconfig with secrets, but got %o", info.Mode().Perm())
+	}
+}
+
+func TestSetNamespace(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	input := "apiVersion: v1\ncontexts:\n- context:\n    cluster: prod\n    namespace: default # team default\n    user: admin\n  name: prod\ncurrent-context: prod\n"
+	configPath := kubetest.WriteKubeConfig(t, input)
+
+	if err := SetNamespace("payments"); err != nil {
+		t.Fatalf("Failed to set namespace: %v", err)
+	}
+
+	expected := strings.Replace(input, "namespace: default", "namespace: payments", 1)
+	if data := readTestFile(t, configPath); data != expected {
+		t.Errorf("Expected only the namespace to change, but got:\n%s", data)
+	}
+
+	if namespace, _ := GetCurrentNamespace(); namespace != "payments" {
+		t.Errorf("Expected current namespace 'payments', but got '%s'", namespace)
+	}
+
+	// The namespace is remembered for offline use
+	cached := GetCachedNamespaces("prod")
+	if len(cached) != 1 || cached[0] != "payments" {
+		t.Errorf("Expected remembered namespaces [payments], but got %v", cached)
+	}
+}
@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// LintFinding is one problem found in a kubeconfig file. Line and Column are
+// 1-based and 0 when the problem has no position, such as an unreadable file.
+type LintFinding struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+}
+
+func (f LintFinding) Location() string {
+	if f.Line == 0 {
+		return f.File
+	}
+	return fmt.Sprintf("%s:%d:%d", f.File, f.Line, f.Column)
+}
+
+// definition is where a name was first defined
+type definition struct {
+	file string
+	line int
+}
+
+type linter struct {
+	findings []LintFinding
+	merged   *KubeConfig
+
+	// First definition of each name per list, across all files
+	defined map[string]map[string]definition
+}
+
+func LintKubeConfig() ([]LintFinding, error) {
+	return DefaultClient().Lint(context.Background())
+}
+
+// Lint checks every kubeconfig file for problems that would make kubectl
+// fail or connect insecurely. Files that do not exist are skipped, as they are
+// when loading.
+func (c *Client) Lint(ctx context.Context) ([]LintFinding, error) {
+	stored, err := c.store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &linter{defined: map[string]map[string]definition{
+		"contexts": {},
+		"clusters": {},
+		"users":    {},
+	}}
+
+	var files []*kubeConfigDocument
+	for _, file := range stored {
+		doc, err := parseStoreFile(file)
+		if err != nil {
+			node := &yaml.Node{}
+			var parseErr *ParseError
+			if errors.As(err, &parseErr) {
+				node.Line = parseErr.Line
+			}
+			l.add(SeverityError, "parse", file.Path, node, "%v", err)
+			continue
+		}
+		files = append(files, doc)
+	}
+
+	l.merged = mergeKubeConfigs(files)
+	for _, file := range files {
+		l.lintFile(file)
+	}
+	return l.findings, nil
+}
+
+func (l *linter) add(severity, check, file string, node *yaml.Node, format string, args ...interface{}) {
+	finding := LintFinding{
+		Severity: severity,
+		Check:    check,
+		File:     file,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	if node != nil {
+		finding.Line = node.Line
+		finding.Column = node.Column
+		if finding.Line > 0 && finding.Column == 0 {
+			finding.Column = 1
+		}
+	}
+	l.findings = append(l.findings, finding)
+}
+
+func (l *linter) lintFile(file *kubeConfigDocument) {
+	mapping := file.mapping()
+
+	keyNode, currentContext := mappingValue(mapping, "current-context")
+	if currentContext != nil && currentContext.Value != "" && l.merged.FindContext(currentContext.Value) == nil {
+		l.add(SeverityError, "dangling-reference", file.Path, keyNode, "current-context points at missing context '%s'", currentContext.Value)
+	}
+
+	for _, listKey := range []string{"clusters", "users", "contexts"} {
+		_, list := mappingValue(mapping, listKey)
+		if list == nil || list.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		// The singular key holds the details, e.g. "cluster" for "clusters"
+		kind := strings.TrimSuffix(listKey, "s")
+		for _, item := range list.Content {
+			name, ok := l.lintName(file, listKey, kind, item)
+			if !ok {
+				continue
+			}
+
+			_, info := mappingValue(item, kind)
+			if info == nil || info.Kind != yaml.MappingNode {
+				l.add(SeverityError, "missing-field", file.Path, item, "%s '%s' has no %s section", kind, name, kind)
+				continue
+			}
+
+			switch kind {
+			case "cluster":
+				l.lintCluster(file, name, info)
+			case "user":
+				l.lintUser(file, name, info)
+			case "context":
+				l.lintContext(file, name, info)
+			}
+		}
+	}
+}
+
+// lintName checks that a list entry has a name that was not used before
+func (l *linter) lintName(file *kubeConfigDocument, listKey, kind string, item *yaml.Node) (string, bool) {
+	_, nameNode := mappingValue(item, "name")
+	if nameNode == nil || nameNode.Value == "" {
+		l.add(SeverityError, "missing-name", file.Path, item, "%s has no name", kind)
+		return "", false
+	}
+
+	name := nameNode.Value
+	if first, ok := l.defined[listKey][name]; ok {
+		// kubectl refuses a file that defines a name twice, but across files
+		// the first definition silently wins
+		if first.file == file.Path {
+			l.add(SeverityError, "duplicate-name", file.Path, nameNode, "%s '%s' is already defined on line %d", kind, name, first.line)
+		} else {
+			l.add(SeverityWarning, "duplicate-name", file.Path, nameNode, "%s '%s' is shadowed by its definition in %s:%d", kind, name, first.file, first.line)
+		}
+	} else {
+		l.defined[listKey][name] = definition{file: file.Path, line: nameNode.Line}
+	}
+	return name, true
+}
+
+func (l *linter) lintCluster(file *kubeConfigDocument, name string, info *yaml.Node) {
+	_, server := mappingValue(info, "server")
+	if server == nil || server.Value == "" {
+		node := server
+		if node == nil {
+			node = info
+		}
+		l.add(SeverityError, "invalid-server", file.Path, node, "cluster '%s' has no server", name)
+	} else if serverURL, err := url.Parse(server.Value); err != nil || serverURL.Host == "" {
+		l.add(SeverityError, "invalid-server", file.Path, server, "cluster '%s' has an invalid server URL '%s'", name, redactURL(server.Value))
+	} else if serverURL.Scheme != "https" {
+		l.add(SeverityWarning, "insecure-server", file.Path, server, "cluster '%s' connects without TLS to %s", name, redactURL(server.Value))
+	}
+
+	if _, skip := mappingValue(info, "insecure-skip-tls-verify"); skip != nil && skip.Value == "true" {
+		l.add(SeverityWarning, "insecure-skip-tls-verify", file.Path, skip, "cluster '%s' does not verify the server certificate", name)
+	}
+
+	l.lintPath(file, "cluster", name, info, "certificate-authority")
+	l.lintBase64(file, "cluster", name, info, "certificate-authority-data")
+}
+
+func (l *linter) lintUser(file *kubeConfigDocument, name string, info *yaml.Node) {
+	for _, key := range []string{"client-certificate", "client-key", "tokenFile"} {
+		l.lintPath(file, "user", name, info, key)
+	}
+	for _, key := range []string{"client-certificate-data", "client-key-data"} {
+		l.lintBase64(file, "user", name, info, key)
+	}
+
+	_, execNode := mappingValue(info, "exec")
+	_, command := mappingValue(execNode, "command")
+	if command != nil && command.Value != "" {
+		path := command.Value
+		// Relative paths with a separator are relative to the kubeconfig file
+		if strings.ContainsRune(path, filepath.Separator) && !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(file.Path), path)
+		}
+		if _, err := exec.LookPath(path); err != nil {
+			l.add(SeverityWarning, "exec-not-found", file.Path, command, "credential plugin '%s' of user '%s' is not installed or not on PATH", command.Value, name)
+		}
+	}
+}
+
+func (l *linter) lintContext(file *kubeConfigDocument, name string, info *yaml.Node) {
+	_, cluster := mappingValue(info, "cluster")
+	if cluster == nil || cluster.Value == "" {
+		l.add(SeverityError, "missing-field", file.Path, info, "context '%s' has no cluster", name)
+	} else if l.merged.FindCluster(cluster.Value) == nil {
+		l.add(SeverityError, "dangling-reference", file.Path, cluster, "context '%s' references missing cluster '%s'", name, cluster.Value)
+	}
+
+	// A context without a user is valid, e.g. for anonymous access
+	_, user := mappingValue(info, "user")
+	if user != nil && user.Value != "" && l.merged.FindUser(user.Value) == nil {
+		l.add(SeverityError, "dangling-reference", file.Path, user, "context '%s' references missing user '%s'", name, user.Value)
+	}
+}
+
+// lintPath checks that a file referenced by key exists
+func (l *linter) lintPath(file *kubeConfigDocument, kind, name string, info *yaml.Node, key string) {
+	_, value := mappingValue(info, key)
+	if value == nil || value.Value == "" {
+		return
+	}
+
+	path := value.Value
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(file.Path), path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		l.add(SeverityError, "missing-file", file.Path, value, "%s of %s '%s' cannot be read: %v", key, kind, name, err)
+	}
+}
+
+// lintBase64 checks that an inline *-data field is valid base64
+func (l *linter) lintBase64(file *kubeConfigDocument, kind, name string, info *yaml.Node, key string) {
+	_, value := mappingValue(info, key)
+	if value == nil || value.Value == "" {
+		return
+	}
+	if _, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value.Value)); err != nil {
+		l.add(SeverityError, "invalid-base64", file.Path, value, "%s of %s '%s' is not valid base64: %v", key, kind, name, err)
+	}
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ryo-nabata/kubec/internal/kubetest"
+)
+
+func TestLintKubeConfig(t *testing.T) {
+	firstContent := `apiVersion: v1
+clusters:
+- cluster:
+    server: https://good.example.com
+    certificate-authority: ca.crt
+  name: good
+- cluster:
+    server: http://plain.example.com
+    insecure-skip-tls-verify: true
+    certificate-authority-data: not*base64
+  name: plain
+- cluster:
+    server: "://broken"
+  name: broken
+- cluster:
+    server: https://again.example.com
+  name: good
+contexts:
+- context:
+    cluster: good
+    user: admin
+  name: ok
+- context:
+    cluster: missing
+    user: ghost
+  name: dangling
+- context:
+    user: admin
+  name: no-cluster
+- context:
+    cluster: good
+users:
+- name: admin
+  user:
+    client-certificate: missing.crt
+    client-key-data: bm90IGEga2V5
+- name: plugin
+  user:
+    exec:
+      command: kubec-test-no-such-plugin
+current-context: nowhere
+`
+	secondContent := `clusters:
+- cluster:
+    server: https://shadow.example.com
+  name: good
+`
+	paths := kubetest.WriteKubeConfigs(t, firstContent, secondContent)
+	first, second := paths[0], paths[1]
+	if err := os.WriteFile(filepath.Join(filepath.Dir(first), "ca.crt"), []byte("ca"), 0600); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+
+	findings, err := LintKubeConfig()
+	if err != nil {
+		t.Fatalf("Failed to lint kubeconfig: %v", err)
+	}
+
+	expected := []LintFinding{
+		{SeverityError, "dangling-reference", first, 41, 1, ""},
+		{SeverityWarning, "insecure-server", first, 8, 13, ""},
+		{SeverityWarning, "insecure-skip-tls-verify", first, 9, 31, ""},
+		{SeverityError, "invalid-base64", first, 10, 33, ""},
+		{SeverityError, "invalid-server", first, 13, 13, ""},
+		{SeverityError, "duplicate-name", first, 17, 9, ""},
+		{SeverityError, "missing-file", first, 35, 25, ""},
+		{SeverityWarning, "exec-not-found", first, 40, 16, ""},
+		{SeverityError, "dangling-reference", first, 24, 14, ""},
+		{SeverityError, "dangling-reference", first, 25, 11, ""},
+		{SeverityError, "missing-field", first, 28, 5, ""},
+		{SeverityError, "missing-name", first, 30, 3, ""},
+		{SeverityWarning, "duplicate-name", second, 4, 9, ""},
+	}
+
+	if len(findings) != len(expected) {
+		for _, finding := range findings {
+			t.Logf("%s: %s: %s [%s]", finding.Location(), finding.Severity, finding.Message, finding.Check)
+		}
+		t.Fatalf("Expected %d findings, but got %d", len(expected), len(findings))
+	}
+	for i, finding := range findings {
+		finding.Message = ""
+		if finding != expected[i] {
+			t.Errorf("Expected finding %d to be %+v, but got %+v", i, expected[i], finding)
+		}
+	}
+}
+
+func TestLintKubeConfigParseError(t *testing.T) {
+	kubetest.WriteKubeConfig(t, "contexts:\n- name: a\n  bad: [\n")
+
+	findings, err := LintKubeConfig()
+	if err != nil {
+		t.Fatalf("Failed to lint kubeconfig: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Check != "parse" || findings[0].Line == 0 {
+		t.Errorf("Expected one parse error with a line number, but got %+v", findings)
+	}
+}
+
+func TestLintKubeConfigClean(t *testing.T) {
+	kubetest.WriteKubeConfig(t, marshalTestKubeConfig(t, KubeConfig{
+		CurrentContext: "ok",
+		Contexts:       []Context{{Name: "ok", Context: ContextInfo{Cluster: "c", User: "u"}}},
+		Clusters:       []Cluster{{Name: "c", Cluster: ClusterInfo{Server: "https://c.example.com"}}},
+		Users:          []User{{Name: "u", User: UserInfo{Token: "t"}}},
+	}))
+
+	findings, err := LintKubeConfig()
+	if err != nil {
+		t.Fatalf("Failed to lint kubeconfig: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("Expected no findings, but got %+v", findings)
+	}
+}
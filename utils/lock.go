@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+var (
+	lockTimeout       = 10 * time.Second
+	lockRetryInterval = 100 * time.Millisecond
+)
+
+// LockFile takes an advisory lock on path by exclusively creating
+// "<path>.lock", the same convention client-go (and so kubectl) uses. It
+// retries until lockTimeout and returns a function that releases the lock.
+func LockFile(path string) (func(), error) {
+	return lockFile(context.Background(), path)
+}
+
+// lockFile is LockFile that also gives up when ctx is done
+func lockFile(ctx context.Context, path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL, 0)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file %s: %w", lockPath, err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock file %s, remove it if no other process is editing the kubeconfig", lockPath)
+		}
+		select {
+		case <-time.After(lockRetryInterval):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up waiting for lock file %s: %w", lockPath, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ryo-nabata/kubec/internal/kubetest"
+)
+
+func TestLockFile(t *testing.T) {
+	originalTimeout := lockTimeout
+	lockTimeout = 200 * time.Millisecond
+	defer func() { lockTimeout = originalTimeout }()
+
+	path := filepath.Join(t.TempDir(), "config")
+
+	unlock, err := LockFile(path)
+	if err != nil {
+		t.Fatalf("Failed to take lock: %v", err)
+	}
+
+	// The lock file follows the client-go convention
+	if !FileExists(path + ".lock") {
+		t.Errorf("Expected lock file %s.lock to exist", path)
+	}
+
+	// A second writer has to wait
+	if _, err := LockFile(path); err == nil {
+		t.Error("Expected error when the lock is already held, but got none")
+	}
+
+	unlock()
+	if FileExists(path + ".lock") {
+		t.Errorf("Expected lock file %s.lock to be removed", path)
+	}
+
+	unlock, err = LockFile(path)
+	if err != nil {
+		t.Fatalf("Failed to take lock after release: %v", err)
+	}
+	unlock()
+}
+
+func TestUpdateKubeConfigFileRetriesOnConcurrentWrite(t *testing.T) {
+	initial := "current-context: a\ncontexts:\n- name: a\n- name: b\n"
+	configPath := kubetest.WriteKubeConfig(t, initial)
+
+	// Another writer changes the file during the first attempt
+	attempts := 0
+	err := updateStoreFile(context.Background(), NewFileStore(configPath), configPath, func(doc *kubeConfigDocument) error {
+		attempts++
+		if attempts == 1 {
+			other := initial + "# written by another tool\n"
+			if err := os.WriteFile(configPath, []byte(other), 0600); err != nil {
+				t.Fatalf("Failed to simulate concurrent write: %v", err)
+			}
+		}
+		return doc.SetCurrentContext("b")
+	})
+	if err != nil {
+		t.Fatalf("Expected update to succeed after retry: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, but got %d", attempts)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read test config: %v", err)
+	}
+	if !strings.Contains(string(data), "# written by another tool") || !strings.Contains(string(data), "current-context: b") {
+		t.Errorf("Expected both changes to be kept, but got:\n%s", data)
+	}
+	if FileExists(configPath + ".lock") {
+		t.Error("Expected lock file to be released")
+	}
+}
+
+func TestUpdateKubeConfigFileAbortsWhenFileKeepsChanging(t *testing.T) {
+	configPath := kubetest.WriteKubeConfig(t, "current-context: a\n")
+
+	attempts := 0
+	err := updateStoreFile(context.Background(), NewFileStore(configPath), configPath, func(doc *kubeConfigDocument) error {
+		attempts++
+		other := []byte("current-context: other\n# change " + strings.Repeat("x", attempts) + "\n")
+		if err := os.WriteFile(configPath, other, 0600); err != nil {
+			t.Fatalf("Failed to simulate concurrent write: %v", err)
+		}
+		return doc.SetCurrentContext("b")
+	})
+	if err == nil {
+		t.Fatal("Expected error when the file keeps changing, but got none")
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read test config: %v", err)
+	}
+	if !strings.Contains(string(data), "current-context: other") {
+		t.Errorf("Expected the other writer's content to be kept, but got:\n%s", data)
+	}
+}
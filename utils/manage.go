@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+)
+
+// updateFiles applies modify to every loaded kubeconfig file selected by match
+func updateFiles(ctx context.Context, store Store, files []*kubeConfigDocument, match func(doc *kubeConfigDocument) bool, modify func(doc *kubeConfigDocument) error) error {
+	for _, file := range files {
+		if !match(file) {
+			continue
+		}
+		err := updateStoreFile(ctx, store, file.Path, modify)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func RenameContext(oldName, newName string) error {
+	return DefaultClient().RenameContext(context.Background(), oldName, newName)
+}
+
+func CopyContext(source, destination, namespace string) error {
+	return DefaultClient().CopyContext(context.Background(), source, destination, namespace)
+}
+
+func DeleteContext(contextName string) ([]string, []string, error) {
+	return DefaultClient().DeleteContext(context.Background(), contextName)
+}
+
+func DeleteClusters(names []string) error {
+	return DefaultClient().DeleteClusters(context.Background(), names)
+}
+
+func DeleteUsers(names []string) error {
+	return DefaultClient().DeleteUsers(context.Background(), names)
+}
+
+// RenameContext renames a context in every file that defines it and moves
+// current-context along when it points at the renamed context
+func (c *Client) RenameContext(ctx context.Context, oldName, newName string) error {
+	files, err := c.loadFiles(ctx)
+	if err != nil {
+		return err
+	}
+	config := mergeKubeConfigs(files)
+
+	if config.FindContext(oldName) == nil {
+		return &ContextNotFoundError{Name: oldName}
+	}
+	if config.FindContext(newName) != nil {
+		return fmt.Errorf("context '%s' already exists", newName)
+	}
+
+	return updateFiles(ctx, c.store, files, func(doc *kubeConfigDocument) bool {
+		return doc.Config.FindContext(oldName) != nil || doc.Config.CurrentContext == oldName
+	}, func(doc *kubeConfigDocument) error {
+		if doc.namedItem("contexts", oldName) != nil {
+			if err := doc.RenameItem("contexts", oldName, newName); err != nil {
+				return err
+			}
+		}
+		if doc.Config.CurrentContext == oldName {
+			return doc.SetCurrentContext(newName)
+		}
+		return nil
+	})
+}
+
+// CopyContext duplicates a context under a new name in the file that defines
+// it, optionally pointing the copy at another namespace
+func (c *Client) CopyContext(ctx context.Context, source, destination, namespace string) error {
+	files, err := c.loadFiles(ctx)
+	if err != nil {
+		return err
+	}
+	config := mergeKubeConfigs(files)
+
+	if config.FindContext(source) == nil {
+		return &ContextNotFoundError{Name: source}
+	}
+	if config.FindContext(destination) != nil {
+		return fmt.Errorf("context '%s' already exists", destination)
+	}
+
+	for _, file := range files {
+		if file.Config.FindContext(source) != nil {
+			return c.update(ctx, file.Path, func(doc *kubeConfigDocument) error {
+				return doc.CopyContext(source, destination, namespace)
+			})
+		}
+	}
+	return &ContextNotFoundError{Name: source}
+}
+
+// DeleteContext removes a context from every file that defines it. It
+// returns the clusters and users the context used that no remaining context
+// references anymore.
+func (c *Client) DeleteContext(ctx context.Context, contextName string) ([]string, []string, error) {
+	files, err := c.loadFiles(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kubeContext := mergeKubeConfigs(files).FindContext(contextName)
+	if kubeContext == nil {
+		return nil, nil, &ContextNotFoundError{Name: contextName}
+	}
+	cluster, user := kubeContext.Context.Cluster, kubeContext.Context.User
+
+	err = c.removeEntries(ctx, files, "contexts", []string{contextName})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config, err := c.Config(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	clusters, users := unreferencedEntries(config)
+
+	var orphanClusters, orphanUsers []string
+	if clusters[cluster] {
+		orphanClusters = append(orphanClusters, cluster)
+	}
+	if users[user] {
+		orphanUsers = append(orphanUsers, user)
+	}
+	return orphanClusters, orphanUsers, nil
+}
+
+func (c *Client) DeleteClusters(ctx context.Context, names []string) error {
+	files, err := c.loadFiles(ctx)
+	if err != nil {
+		return err
+	}
+	return c.removeEntries(ctx, files, "clusters", names)
+}
+
+func (c *Client) DeleteUsers(ctx context.Context, names []string) error {
+	files, err := c.loadFiles(ctx)
+	if err != nil {
+		return err
+	}
+	return c.removeEntries(ctx, files, "users", names)
+}
+
+// removeEntries deletes the named entries of a list from every file
+func (c *Client) removeEntries(ctx context.Context, files []*kubeConfigDocument, listKey string, names []string) error {
+	return updateFiles(ctx, c.store, files, func(doc *kubeConfigDocument) bool {
+		for _, name := range names {
+			if doc.namedItem(listKey, name) != nil {
+				return true
+			}
+		}
+		return false
+	}, func(doc *kubeConfigDocument) error {
+		return doc.RemoveItems(listKey, names)
+	})
+}
+
+// unreferencedEntries returns the clusters and users that are defined but
+// not used by any context
+func unreferencedEntries(config *KubeConfig) (map[string]bool, map[string]bool) {
+	clusters := make(map[string]bool)
+	users := make(map[string]bool)
+	for _, cluster := range config.Clusters {
+		clusters[cluster.Name] = true
+	}
+	for _, user := range config.Users {
+		users[user.Name] = true
+	}
+	for _, context := range config.Contexts {
+		delete(clusters, context.Context.Cluster)
+		delete(users, context.Context.User)
+	}
+	return clusters, users
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ryo-nabata/kubec/internal/kubetest"
+)
+
+const manageKubeConfig = `apiVersion: v1
+clusters:
+- cluster:
+    server: https://prod.example.com
+  name: prod-cluster
+- cluster:
+    server: https://dev.example.com
+  name: dev-cluster
+contexts:
+- context:
+    cluster: prod-cluster
+    user: prod-user
+  name: prod
+# development context
+- context:
+    cluster: dev-cluster
+    user: dev-user
+  name: dev
+current-context: prod
+kind: Config
+users:
+- name: prod-user
+  user:
+    token: prod-token
+- name: dev-user
+  user:
+    token: dev-token
+`
+
+func TestRenameContext(t *testing.T) {
+	configPath := kubetest.WriteKubeConfig(t, manageKubeConfig)
+
+	if err := RenameContext("prod", "production"); err != nil {
+		t.Fatalf("Failed to rename context: %v", err)
+	}
+
+	expected := strings.Replace(manageKubeConfig, "  name: prod\n", "  name: production\n", 1)
+	expected = strings.Replace(expected, "current-context: prod\n", "current-context: production\n", 1)
+	if data := readTestFile(t, configPath); data != expected {
+		t.Errorf("Expected only the name and current-context to change, but got:\n%s", data)
+	}
+
+	if err := RenameContext("dev", "production"); err == nil {
+		t.Error("Expected error when renaming onto an existing context, but got none")
+	}
+	if err := RenameContext("missing", "other"); err == nil {
+		t.Error("Expected error when renaming a missing context, but got none")
+	}
+}
+
+func TestDeleteContext(t *testing.T) {
+	configPath := kubetest.WriteKubeConfig(t, manageKubeConfig)
+
+	clusters, users, err := DeleteContext("dev")
+	if err != nil {
+		t.Fatalf("Failed to delete context: %v", err)
+	}
+
+	// The comment above the entry describes dev and goes with it
+	expected := strings.Replace(manageKubeConfig, "# development context\n- context:\n    cluster: dev-cluster\n    user: dev-user\n  name: dev\n", "", 1)
+	if data := readTestFile(t, configPath); data != expected {
+		t.Errorf("Expected only the context entry to be removed, but got:\n%s", data)
+	}
+
+	if len(clusters) != 1 || clusters[0] != "dev-cluster" || len(users) != 1 || users[0] != "dev-user" {
+		t.Fatalf("Expected dev-cluster and dev-user to be unreferenced, but got %v %v", clusters, users)
+	}
+
+	if err := DeleteClusters(clusters); err != nil {
+		t.Fatalf("Failed to delete clusters: %v", err)
+	}
+	if err := DeleteUsers(users); err != nil {
+		t.Fatalf("Failed to delete users: %v", err)
+	}
+
+	config, err := DefaultClient().Config(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to load kubeconfig: %v", err)
+	}
+	if len(config.Clusters) != 1 || len(config.Users) != 1 || len(config.Contexts) != 1 {
+		t.Errorf("Expected one context, cluster and user left, but got %+v", config)
+	}
+	if data := readTestFile(t, configPath); !strings.HasSuffix(data, "users:\n- name: prod-user\n  user:\n    token: prod-token\n") {
+		t.Errorf("Expected the last user entry to be removed cleanly, but got:\n%s", data)
+	}
+
+	if _, _, err := DeleteContext("dev"); err == nil {
+		t.Error("Expected error when deleting a missing context, but got none")
+	}
+}
+
+func TestDeleteContextKeepsSharedEntries(t *testing.T) {
+	kubetest.WriteKubeConfig(t, manageKubeConfig)
+
+	if err := CopyContext("prod", "prod-copy", ""); err != nil {
+		t.Fatalf("Failed to copy context: %v", err)
+	}
+
+	clusters, users, err := DeleteContext("prod")
+	if err != nil {
+		t.Fatalf("Failed to delete context: %v", err)
+	}
+	if len(clusters) != 0 || len(users) != 0 {
+		t.Errorf("Expected entries used by the copy to stay referenced, but got %v %v", clusters, users)
+	}
+}
+
+func TestCopyContext(t *testing.T) {
+	configPath := kubetest.WriteKubeConfig(t, manageKubeConfig)
+
+	if err := CopyContext("prod", "prod-payments", "payments"); err != nil {
+		t.Fatalf("Failed to copy context: %v", err)
+	}
+
+	copied := "- context:\n    namespace: payments\n    cluster: prod-cluster\n    user: prod-user\n  name: prod-payments\n"
+	expected := strings.Replace(manageKubeConfig, "  name: prod\n", "  name: prod\n"+copied, 1)
+	if data := readTestFile(t, configPath); data != expected {
+		t.Errorf("Expected the copy right after the original, but got:\n%s", data)
+	}
+
+	if err := CopyContext("prod", "dev", ""); err == nil {
+		t.Error("Expected error when copying onto an existing context, but got none")
+	}
+}
+
+func TestRemoveItemFlowStyleFallback(t *testing.T) {
+	doc, err := parseKubeConfigDocument("config", []byte("contexts: [{name: a}, {name: b}]\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	if err := doc.RemoveItem("contexts", "a"); err != nil {
+		t.Fatalf("Failed to remove item: %v", err)
+	}
+	if len(doc.Config.Contexts) != 1 || doc.Config.Contexts[0].Name != "b" {
+		t.Errorf("Expected only context 'b' to remain, but got %+v", doc.Config.Contexts)
+	}
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+)
+
+// MatchContexts finds the contexts meant by query. An exact match wins, then
+// prefix, substring and fuzzy matches are tried in turn and the first kind
+// that matches anything is returned. Fuzzy matches are ranked best first.
+func MatchContexts(query string, contexts []string) []string {
+	for _, context := range contexts {
+		if context == query {
+			return []string{context}
+		}
+	}
+
+	lowerQuery := strings.ToLower(query)
+	matchers := []func(string) bool{
+		func(context string) bool { return strings.HasPrefix(strings.ToLower(context), lowerQuery) },
+		func(context string) bool { return strings.Contains(strings.ToLower(context), lowerQuery) },
+	}
+	for _, matcher := range matchers {
+		var matches []string
+		for _, context := range contexts {
+			if matcher(context) {
+				matches = append(matches, context)
+			}
+		}
+		if len(matches) > 0 {
+			return matches
+		}
+	}
+
+	type fuzzyResult struct {
+		context string
+		score   int
+	}
+	var results []fuzzyResult
+	for _, context := range contexts {
+		if score, _, ok := FuzzyMatch(query, context); ok {
+			results = append(results, fuzzyResult{context, score})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	var matches []string
+	for _, result := range results {
+		matches = append(matches, result.context)
+	}
+	return matches
+}
+
+// SuggestContexts returns up to max contexts that are close to query by edit
+// distance, closest first, for "did you mean" hints
+func SuggestContexts(query string, contexts []string, max int) []string {
+	threshold := len(query) / 3
+	if threshold < 2 {
+		threshold = 2
+	}
+
+	type suggestion struct {
+		context  string
+		distance int
+	}
+	var suggestions []suggestion
+	for _, context := range contexts {
+		distance := LevenshteinDistance(strings.ToLower(query), strings.ToLower(context))
+		// A suggestion must share something with the query
+		if distance <= threshold && distance < len([]rune(context)) {
+			suggestions = append(suggestions, suggestion{context, distance})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+
+	var result []string
+	for i := 0; i < len(suggestions) && i < max; i++ {
+		result = append(result, suggestions[i].context)
+	}
+	return result
+}
+
+func LevenshteinDistance(a, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(target)]
+}
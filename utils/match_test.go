@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchContexts(t *testing.T) {
+	contexts := []string{
+		"prod",
+		"prod-eu",
+		"staging",
+		"arn:aws:eks:eu-west-1:123456789012:cluster/payments-prod",
+		"dev",
+	}
+
+	testCases := []struct {
+		query    string
+		expected []string
+	}{
+		// Exact match wins over prefix matches
+		{"prod", []string{"prod"}},
+		{"stag", []string{"staging"}},
+		{"STAG", []string{"staging"}},
+		{"pro", []string{"prod", "prod-eu"}},
+		{"payments", []string{"arn:aws:eks:eu-west-1:123456789012:cluster/payments-prod"}},
+		{"sgn", []string{"staging"}},
+		{"xyz", nil},
+	}
+
+	for _, testCase := range testCases {
+		matches := MatchContexts(testCase.query, contexts)
+		if strings.Join(matches, ",") != strings.Join(testCase.expected, ",") {
+			t.Errorf("MatchContexts(%q): expected %v, but got %v", testCase.query, testCase.expected, matches)
+		}
+	}
+}
+
+func TestSuggestContexts(t *testing.T) {
+	contexts := []string{"production", "staging", "development", "prod"}
+
+	suggestions := SuggestContexts("prdo", contexts, 3)
+	if len(suggestions) == 0 || suggestions[0] != "prod" {
+		t.Errorf("Expected 'prod' as the first suggestion, but got %v", suggestions)
+	}
+
+	if suggestions := SuggestContexts("zzzzzzzz", contexts, 3); len(suggestions) != 0 {
+		t.Errorf("Expected no suggestions, but got %v", suggestions)
+	}
+
+	if suggestions := SuggestContexts("stagign", contexts, 1); len(suggestions) != 1 || suggestions[0] != "staging" {
+		t.Errorf("Expected ['staging'], but got %v", suggestions)
+	}
+}
+
+func TestLevenshteinDistance(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"prod", "prod", 0},
+		{"prod", "prdo", 2},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+
+	for _, testCase := range testCases {
+		if distance := LevenshteinDistance(testCase.a, testCase.b); distance != testCase.distance {
+			t.Errorf("LevenshteinDistance(%q, %q): expected %d, but got %d", testCase.a, testCase.b, testCase.distance, distance)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+func getNamespaceCachePath() (string, error) {
+	stateDir, err := GetStateDirectory()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "namespaces.yaml"), nil
+}
+
+func loadNamespaceCache() map[string][]string {
+	cache := make(map[string][]string)
+	cachePath, err := getNamespaceCachePath()
+	if err != nil {
+		return cache
+	}
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return cache
+	}
+	// A broken cache is treated as empty, it is rebuilt on the next listing
+	if err := yaml.Unmarshal(data, &cache); err != nil || cache == nil {
+		return make(map[string][]string)
+	}
+	return cache
+}
+
+// GetCachedNamespaces returns the namespaces remembered for a context
+func GetCachedNamespaces(contextName string) []string {
+	return loadNamespaceCache()[contextName]
+}
+
+func GetNamespaceCandidates() ([]string, string, error) {
+	return DefaultClient().NamespaceCandidates(context.Background())
+}
+
+// NamespaceCandidates returns the namespaces known for the current context
+// without contacting the cluster: the remembered ones and the namespace the
+// context is set to, which is also returned on its own. Only the default
+// client remembers namespaces.
+func (c *Client) NamespaceCandidates(ctx context.Context) ([]string, string, error) {
+	config, err := c.Config(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	context := config.FindContext(config.CurrentContext)
+	if context == nil {
+		return nil, "", fmt.Errorf("no current context is set")
+	}
+
+	current := context.Context.Namespace
+	var namespaces []string
+	if c.recordState {
+		namespaces = GetCachedNamespaces(context.Name)
+	}
+	if current != "" && !containsString(namespaces, current) {
+		namespaces = append(namespaces, current)
+		sort.Strings(namespaces)
+	}
+	return namespaces, current, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// rememberNamespaces stores namespaces for a context. With replace the list
+// from the cluster becomes the new cache, otherwise the names are added.
+func rememberNamespaces(contextName string, namespaces []string, replace bool) error {
+	cachePath, err := getNamespaceCachePath()
+	if err != nil {
+		return err
+	}
+	if err := CreateDirectoryIfNotExists(filepath.Dir(cachePath)); err != nil {
+		return err
+	}
+
+	unlock, err := LockFile(cachePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cache := loadNamespaceCache()
+	merged := make(map[string]bool)
+	if !replace {
+		for _, namespace := range cache[contextName] {
+			merged[namespace] = true
+		}
+	}
+	for _, namespace := range namespaces {
+		merged[namespace] = true
+	}
+
+	var list []string
+	for namespace := range merged {
+		list = append(list, namespace)
+	}
+	sort.Strings(list)
+	cache[contextName] = list
+
+	data, err := yaml.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("failed to prepare namespace cache write: %w", err)
+	}
+	return WriteFileAtomic(cachePath, data, 0600)
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Orders for the context list
+const (
+	SortMRU          = "mru"
+	SortAlphabetical = "alpha"
+	SortCluster      = "cluster"
+)
+
+var SortModes = []string{SortMRU, SortAlphabetical, SortCluster}
+
+// IsSortMode reports whether mode is one of SortModes
+func IsSortMode(mode string) bool {
+	for _, sortMode := range SortModes {
+		if mode == sortMode {
+			return true
+		}
+	}
+	return false
+}
+
+// SortContexts orders contexts by mode. MRU puts the most recently used
+// contexts first according to history, followed by unused ones in
+// alphabetical order. Cluster groups contexts by the cluster they point to.
+func SortContexts(contexts []Context, mode string, history []HistoryEntry) ([]Context, error) {
+	sorted := make([]Context, len(contexts))
+	copy(sorted, contexts)
+
+	switch mode {
+	case SortAlphabetical:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Name < sorted[j].Name
+		})
+	case SortCluster:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if sorted[i].Context.Cluster != sorted[j].Context.Cluster {
+				return sorted[i].Context.Cluster < sorted[j].Context.Cluster
+			}
+			return sorted[i].Name < sorted[j].Name
+		})
+	case SortMRU:
+		lastUsed := GetLastUsed(history)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			usedI, usedJ := lastUsed[sorted[i].Name], lastUsed[sorted[j].Name]
+			if !usedI.Equal(usedJ) {
+				return usedI.After(usedJ)
+			}
+			return sorted[i].Name < sorted[j].Name
+		})
+	default:
+		return nil, fmt.Errorf("unknown sort mode '%s', must be one of %v", mode, SortModes)
+	}
+
+	return sorted, nil
+}
+
+// GetLastUsed returns when each context was last switched to
+func GetLastUsed(history []HistoryEntry) map[string]time.Time {
+	lastUsed := make(map[string]time.Time)
+	for _, entry := range history {
+		if entry.Time.After(lastUsed[entry.Context]) {
+			lastUsed[entry.Context] = entry.Time
+		}
+	}
+	return lastUsed
+}
+
+func GetSortedContexts(mode string) ([]ContextSummary, error) {
+	return DefaultClient().SortedContexts(context.Background(), mode)
+}
+
+// SortedContexts describes the contexts in the given order
+func (c *Client) SortedContexts(ctx context.Context, mode string) ([]ContextSummary, error) {
+	config, err := c.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without history MRU falls back to alphabetical order
+	history, _ := GetHistory()
+	contexts, err := SortContexts(config.Contexts, mode, history)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]ContextSummary, len(contexts))
+	for i, context := range contexts {
+		summaries[i] = config.Describe(context)
+	}
+	return summaries, nil
+}
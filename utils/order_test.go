@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSortContexts(t *testing.T) {
+	contexts := []Context{
+		{Name: "staging", Context: ContextInfo{Cluster: "eu"}},
+		{Name: "prod", Context: ContextInfo{Cluster: "us"}},
+		{Name: "dev", Context: ContextInfo{Cluster: "eu"}},
+		{Name: "qa", Context: ContextInfo{Cluster: "us"}},
+	}
+	now := time.Now()
+	history := []HistoryEntry{
+		{Time: now.Add(-3 * time.Hour), Context: "prod"},
+		{Time: now.Add(-2 * time.Hour), Context: "staging"},
+		{Time: now.Add(-1 * time.Hour), Context: "prod"},
+	}
+
+	testCases := []struct {
+		mode     string
+		expected string
+	}{
+		{SortMRU, "prod,staging,dev,qa"},
+		{SortAlphabetical, "dev,prod,qa,staging"},
+		{SortCluster, "dev,staging,prod,qa"},
+	}
+
+	for _, testCase := range testCases {
+		sorted, err := SortContexts(contexts, testCase.mode, history)
+		if err != nil {
+			t.Fatalf("Failed to sort by %s: %v", testCase.mode, err)
+		}
+		if contextNames(sorted) != testCase.expected {
+			t.Errorf("Expected %s order %s, but got %v", testCase.mode, testCase.expected, sorted)
+		}
+	}
+
+	// The input is left untouched
+	if contexts[0].Name != "staging" {
+		t.Errorf("Expected input order to be kept, but got %s first", contexts[0].Name)
+	}
+
+	if _, err := SortContexts(contexts, "random", history); err == nil {
+		t.Error("Expected error for unknown sort mode, but got none")
+	}
+}
+
+func TestSortContextsMRUWithoutHistory(t *testing.T) {
+	contexts := []Context{{Name: "b"}, {Name: "c"}, {Name: "a"}}
+
+	sorted, err := SortContexts(contexts, SortMRU, nil)
+	if err != nil {
+		t.Fatalf("Failed to sort: %v", err)
+	}
+	if contextNames(sorted) != "a,b,c" {
+		t.Errorf("Expected alphabetical order without history, but got %v", sorted)
+	}
+}
+
+func contextNames(contexts []Context) string {
+	var names []string
+	for _, context := range contexts {
+		names = append(names, context.Name)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,42 @@
+package utils
+
+// ProtectionExtension is the kubeconfig extension that protects a context
+// without a kubec config file:
+//
+//	contexts:
+//	- name: prod
+//	  context:
+//	    cluster: prod
+//	    extensions:
+//	    - name: kubec
+//	      extension:
+//	        protected: true
+const ProtectionExtension = "kubec"
+
+// Protected reports whether the kubec extension of a context protects it
+func (c ContextInfo) Protected() bool {
+	for _, extension := range c.Extensions {
+		if extension.Name != ProtectionExtension {
+			continue
+		}
+		if protected, ok := extension.Extension["protected"].(bool); ok && protected {
+			return true
+		}
+	}
+	return false
+}
+
+// IsProtected reports whether switching to a context needs a typed
+// confirmation, because a protected entry of the settings matches its name or
+// its kubeconfig extension protects it
+func IsProtected(settings *Settings, config *KubeConfig, contextName string) bool {
+	for _, context := range settings.Contexts {
+		if context.Protected && MatchPattern(context.Match, contextName) {
+			return true
+		}
+	}
+	if context := config.FindContext(contextName); context != nil {
+		return context.Context.Protected()
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestIsProtected(t *testing.T) {
+	var config KubeConfig
+	err := yaml.Unmarshal([]byte(`apiVersion: v1
+kind: Config
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    extensions:
+    - name: other-tool
+      extension:
+        protected: true
+- name: payments-prod
+  context:
+    cluster: payments
+- name: staging
+  context:
+    cluster: staging
+    extensions:
+    - name: kubec
+      extension:
+        protected: true
+`), &config)
+	if err != nil {
+		t.Fatalf("Failed to parse kubeconfig: %v", err)
+	}
+
+	settings := &Settings{Contexts: []ContextSettings{
+		{Match: "*-prod", Protected: true},
+		{Match: "dev", Env: map[string]string{"AWS_PROFILE": "dev"}},
+	}}
+
+	tests := map[string]bool{
+		"dev":           false,
+		"payments-prod": true,
+		"staging":       true,
+		"other-prod":    true,
+		"missing":       false,
+	}
+	for contextName, expected := range tests {
+		if protected := IsProtected(settings, &config, contextName); protected != expected {
+			t.Errorf("Expected IsProtected(%s) to be %v, but got %v", contextName, expected, protected)
+		}
+	}
+}
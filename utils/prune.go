@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"context"
+	"sort"
+)
+
+// PruneReport is the result of checking how contexts, clusters and users
+// reference each other across all kubeconfig files
+type PruneReport struct {
+	// Clusters and users no context references
+	OrphanClusters []string
+	OrphanUsers    []string
+
+	// Contexts that point at clusters or users that do not exist
+	Dangling []DanglingReference
+
+	// Names defined more than once, only the first definition is used
+	Duplicates []DuplicateDefinition
+}
+
+type DanglingReference struct {
+	Context string
+	Kind    string
+	Name    string
+}
+
+type DuplicateDefinition struct {
+	Kind  string
+	Name  string
+	Files []string
+}
+
+func (r *PruneReport) HasOrphans() bool {
+	return len(r.OrphanClusters) > 0 || len(r.OrphanUsers) > 0
+}
+
+func AnalyzeKubeConfig() (*PruneReport, error) {
+	return DefaultClient().Analyze(context.Background())
+}
+
+func PruneDiff(report *PruneReport) (string, error) {
+	return DefaultClient().PruneDiff(context.Background(), report)
+}
+
+func Prune(report *PruneReport) error {
+	return DefaultClient().Prune(context.Background(), report)
+}
+
+// Analyze checks how contexts, clusters and users reference each other
+func (c *Client) Analyze(ctx context.Context) (*PruneReport, error) {
+	files, err := c.loadFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config := mergeKubeConfigs(files)
+	report := &PruneReport{}
+
+	clusters, users := unreferencedEntries(config)
+	report.OrphanClusters = sortedKeys(clusters)
+	report.OrphanUsers = sortedKeys(users)
+
+	for _, context := range config.Contexts {
+		if name := context.Context.Cluster; name != "" && config.FindCluster(name) == nil {
+			report.Dangling = append(report.Dangling, DanglingReference{Context: context.Name, Kind: "cluster", Name: name})
+		}
+		if name := context.Context.User; name != "" && config.FindUser(name) == nil {
+			report.Dangling = append(report.Dangling, DanglingReference{Context: context.Name, Kind: "user", Name: name})
+		}
+	}
+
+	report.Duplicates = findDuplicates(files)
+	return report, nil
+}
+
+// findDuplicates lists names that are defined more than once, in the same
+// file or across files
+func findDuplicates(files []*kubeConfigDocument) []DuplicateDefinition {
+	type key struct{ kind, name string }
+	definitions := make(map[key][]string)
+	var order []key
+
+	add := func(kind, name, path string) {
+		k := key{kind, name}
+		if _, ok := definitions[k]; !ok {
+			order = append(order, k)
+		}
+		definitions[k] = append(definitions[k], path)
+	}
+	for _, file := range files {
+		for _, context := range file.Config.Contexts {
+			add("context", context.Name, file.Path)
+		}
+		for _, cluster := range file.Config.Clusters {
+			add("cluster", cluster.Name, file.Path)
+		}
+		for _, user := range file.Config.Users {
+			add("user", user.Name, file.Path)
+		}
+	}
+
+	var duplicates []DuplicateDefinition
+	for _, k := range order {
+		if len(definitions[k]) > 1 {
+			duplicates = append(duplicates, DuplicateDefinition{Kind: k.kind, Name: k.name, Files: definitions[k]})
+		}
+	}
+	return duplicates
+}
+
+// PruneDiff shows the changes Prune would make, as a unified diff per file.
+// Secret values of users are redacted on both sides of the diff.
+func (c *Client) PruneDiff(ctx context.Context, report *PruneReport) (string, error) {
+	files, err := c.loadFiles(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var diff string
+	for _, file := range files {
+		pruned, err := parseKubeConfigDocument(file.Path, file.Data)
+		if err != nil {
+			return "", err
+		}
+		if err := removeOrphans(pruned, report); err != nil {
+			return "", err
+		}
+		before, err := file.redactedData()
+		if err != nil {
+			return "", err
+		}
+		after, err := pruned.redactedData()
+		if err != nil {
+			return "", err
+		}
+		diff += UnifiedDiff(file.Path, file.Path, before, after)
+	}
+	return diff, nil
+}
+
+// Prune removes the orphaned clusters and users of a report
+func (c *Client) Prune(ctx context.Context, report *PruneReport) error {
+	files, err := c.loadFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	return updateFiles(ctx, c.store, files, func(doc *kubeConfigDocument) bool {
+		for _, name := range report.OrphanClusters {
+			if doc.namedItem("clusters", name) != nil {
+				return true
+			}
+		}
+		for _, name := range report.OrphanUsers {
+			if doc.namedItem("users", name) != nil {
+				return true
+			}
+		}
+		return false
+	}, func(doc *kubeConfigDocument) error {
+		return removeOrphans(doc, report)
+	})
+}
+
+func removeOrphans(doc *kubeConfigDocument, report *PruneReport) error {
+	if err := doc.RemoveItems("clusters", report.OrphanClusters); err != nil {
+		return err
+	}
+	return doc.RemoveItems("users", report.OrphanUsers)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
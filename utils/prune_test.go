@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ryo-nabata/kubec/internal/kubetest"
+)
+
+func TestAnalyzeAndPrune(t *testing.T) {
+	firstContent := `contexts:
+- context:
+    cluster: used
+    user: used-user
+  name: ok
+- context:
+    cluster: missing
+    user: used-user
+  name: broken
+clusters:
+- cluster:
+    server: https://used
+  name: used
+- cluster:
+    server: https://old
+  name: old-eks
+users:
+- name: used-user
+  user: {}
+- name: old-user
+  user:
+    token: x
+`
+	secondContent := `clusters:
+- cluster:
+    server: https://shadowed
+  name: used
+- cluster:
+    server: https://old
+  name: old-eks
+`
+	paths := kubetest.WriteKubeConfigs(t, firstContent, secondContent)
+	first, second := paths[0], paths[1]
+
+	report, err := AnalyzeKubeConfig()
+	if err != nil {
+		t.Fatalf("Failed to analyze kubeconfig: %v", err)
+	}
+
+	if strings.Join(report.OrphanClusters, ",") != "old-eks" || strings.Join(report.OrphanUsers, ",") != "old-user" {
+		t.Errorf("Expected orphans old-eks and old-user, but got %v %v", report.OrphanClusters, report.OrphanUsers)
+	}
+	if len(report.Dangling) != 1 || report.Dangling[0].Context != "broken" || report.Dangling[0].Name != "missing" {
+		t.Errorf("Expected context 'broken' to reference missing cluster, but got %+v", report.Dangling)
+	}
+	if len(report.Duplicates) != 2 || report.Duplicates[0].Name != "used" || len(report.Duplicates[0].Files) != 2 {
+		t.Errorf("Expected clusters 'used' and 'old-eks' to be duplicated, but got %+v", report.Duplicates)
+	}
+
+	// A dry run changes nothing
+	diff, err := PruneDiff(report)
+	if err != nil {
+		t.Fatalf("Failed to compute prune diff: %v", err)
+	}
+	for _, expected := range []string{"--- " + first, "--- " + second, "-  name: old-eks", "-- name: old-user"} {
+		if !strings.Contains(diff, expected) {
+			t.Errorf("Expected diff to contain %q, but got:\n%s", expected, diff)
+		}
+	}
+	if strings.Contains(diff, "token: x") || !strings.Contains(diff, "-    token: REDACTED") {
+		t.Errorf("Expected the token to be redacted in the diff, but got:\n%s", diff)
+	}
+	if readTestFile(t, first) != firstContent {
+		t.Error("Expected dry run to leave the file untouched")
+	}
+
+	if err := Prune(report); err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+
+	report, err = AnalyzeKubeConfig()
+	if err != nil {
+		t.Fatalf("Failed to analyze kubeconfig: %v", err)
+	}
+	if report.HasOrphans() {
+		t.Errorf("Expected no orphans after pruning, but got %v %v", report.OrphanClusters, report.OrphanUsers)
+	}
+	if data := readTestFile(t, second); strings.Contains(data, "old-eks") || !strings.Contains(data, "https://shadowed") {
+		t.Errorf("Expected only the orphan to be removed from %s, but got:\n%s", second, data)
+	}
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables that mark a shell as running a kubec session
+const (
+	// SessionEnv holds the path of the session kubeconfig file
+	SessionEnv = "KUBEC_SESSION"
+
+	// OriginalKubeConfigEnv holds KUBECONFIG as it was before the session
+	// started, empty when it was not set
+	OriginalKubeConfigEnv = "KUBEC_ORIGINAL_KUBECONFIG"
+)
+
+const sessionDirPattern = "kubec-session-"
+
+// sessionMaxAge is how long a session is kept after its last switch where
+// the process owning it cannot be checked
+const sessionMaxAge = 24 * time.Hour
+
+// Session is a kubeconfig file private to one shell. It comes first in
+// KUBECONFIG and sets current-context, so switching inside the session only
+// changes this file and other shells keep their context.
+type Session struct {
+	Dir  string
+	Path string
+
+	// KubeConfig is the KUBECONFIG value for the session: the session file
+	// followed by the files the user had before
+	KubeConfig string
+
+	// OriginalKubeConfig is KUBECONFIG as it was before any session
+	OriginalKubeConfig string
+}
+
+// CurrentSession returns the session kubeconfig file of this shell, or ""
+func CurrentSession() string {
+	session := os.Getenv(SessionEnv)
+	if session == "" || !FileExists(session) {
+		return ""
+	}
+	return session
+}
+
+func CreateSession(contextName string, owner int) (*Session, error) {
+	return DefaultClient().CreateSession(context.Background(), contextName, owner)
+}
+
+// CreateSession writes a new session file in a private temporary directory,
+// with current-context set to contextName. The session belongs to the
+// process owner, when that process is gone the session is removed by the next
+// CreateSession. Where processes cannot be checked it expires instead.
+func (c *Client) CreateSession(ctx context.Context, contextName string, owner int) (*Session, error) {
+	// A session chains to the files of KUBECONFIG, not to those of the store
+	if !c.recordState {
+		return nil, fmt.Errorf("sessions are only available with the default client")
+	}
+	sweepSessions()
+
+	// A session started inside another one chains to the same real files
+	original := os.Getenv("KUBECONFIG")
+	if os.Getenv(SessionEnv) != "" {
+		original = os.Getenv(OriginalKubeConfigEnv)
+	}
+	var paths []string
+	if original != "" {
+		paths = filepath.SplitList(original)
+	} else {
+		kubeDir, err := GetKubeDirectory()
+		if err != nil {
+			return nil, err
+		}
+		paths = []string{filepath.Join(kubeDir, "config")}
+	}
+
+	config, err := c.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if config.FindContext(contextName) == nil {
+		return nil, &ContextNotFoundError{Name: contextName}
+	}
+
+	// The context this shell used so far, for the switch history
+	previousContext := config.CurrentContext
+
+	// MkdirTemp creates the directory readable by the owner only
+	dir, err := os.MkdirTemp("", sessionDirPattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+	session := &Session{
+		Dir:                dir,
+		Path:               filepath.Join(dir, "config"),
+		OriginalKubeConfig: original,
+	}
+	session.KubeConfig = strings.Join(append([]string{session.Path}, paths...), string(filepath.ListSeparator))
+
+	content := fmt.Sprintf("apiVersion: v1\nkind: Config\ncurrent-context: %s\n", renderScalar(contextName, 0))
+	if err := WriteFileAtomic(session.Path, []byte(content), 0600); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "owner"), []byte(strconv.Itoa(owner)), 0600); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to write session file: %w", err)
+	}
+
+	if c.recordState && previousContext != contextName {
+		recordSwitch(previousContext, contextName)
+	}
+	return session, nil
+}
+
+// RemoveSession deletes a session file and its directory. Only directories
+// created by CreateSession are removed.
+func RemoveSession(path string) error {
+	dir := filepath.Dir(path)
+	if !strings.HasPrefix(filepath.Base(dir), sessionDirPattern) {
+		return fmt.Errorf("%s is not a kubec session", path)
+	}
+	return os.RemoveAll(dir)
+}
+
+// sweepSessions removes the sessions of processes that have exited, e.g.
+// shells that were closed without ending their session
+func sweepSessions() {
+	dirs, _ := filepath.Glob(filepath.Join(os.TempDir(), sessionDirPattern+"*"))
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, "owner"))
+		if err != nil {
+			continue
+		}
+		owner, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil || sessionAlive(dir, owner) {
+			continue
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+// sessionExpired reports whether the session file in dir has not been
+// written, by its creation or a switch, for sessionMaxAge
+func sessionExpired(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, "config"))
+	return err != nil || time.Since(info.ModTime()) > sessionMaxAge
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ryo-nabata/kubec/internal/kubetest"
+)
+
+// startTestSession creates a session for context and points the environment
+// at it, the way a shell evaluating "kubec env" would
+func startTestSession(t *testing.T, contextName string) *Session {
+	t.Helper()
+	session, err := CreateSession(contextName, os.Getpid())
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	t.Cleanup(func() { RemoveSession(session.Path) })
+	for _, change := range session.Env() {
+		t.Setenv(change.Name, change.Value)
+	}
+	return session
+}
+
+func TestSessionSwitchesOnlyTheSessionFile(t *testing.T) {
+	configPath := kubetest.WriteKubeConfig(t, manageKubeConfig)
+	session := startTestSession(t, "dev")
+
+	info, err := os.Stat(session.Dir)
+	if err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("Expected a private session directory, but got %v (%v)", info.Mode().Perm(), err)
+	}
+	if session.KubeConfig != session.Path+string(filepath.ListSeparator)+configPath {
+		t.Errorf("Expected the session file to chain to %s, but got %s", configPath, session.KubeConfig)
+	}
+	if CurrentSession() != session.Path {
+		t.Errorf("Expected current session %s, but got %s", session.Path, CurrentSession())
+	}
+	if current, _ := GetCurrentContext(); current != "dev" {
+		t.Errorf("Expected session context dev, but got %s", current)
+	}
+
+	if err := SetCurrentContext("prod"); err != nil {
+		t.Fatalf("Failed to switch context: %v", err)
+	}
+	if err := SetNamespace("payments"); err != nil {
+		t.Fatalf("Failed to switch namespace: %v", err)
+	}
+
+	if data := readTestFile(t, configPath); data != manageKubeConfig {
+		t.Errorf("Expected the shared kubeconfig to be untouched, but got:\n%s", data)
+	}
+	if current, _ := GetCurrentContext(); current != "prod" {
+		t.Errorf("Expected session context prod, but got %s", current)
+	}
+	if namespace, _ := GetCurrentNamespace(); namespace != "payments" {
+		t.Errorf("Expected namespace payments in the session, but got %s", namespace)
+	}
+
+	// A nested session chains to the same real files, not to this session
+	nested, err := CreateSession("dev", os.Getpid())
+	if err != nil {
+		t.Fatalf("Failed to create nested session: %v", err)
+	}
+	defer RemoveSession(nested.Path)
+	if strings.Contains(nested.KubeConfig, session.Path) {
+		t.Errorf("Expected the nested session not to chain to the outer one, but got %s", nested.KubeConfig)
+	}
+
+	if err := RemoveSession(session.Path); err != nil {
+		t.Fatalf("Failed to remove session: %v", err)
+	}
+	if FileExists(session.Dir) {
+		t.Error("Expected the session directory to be removed")
+	}
+	if err := RemoveSession(configPath); err == nil {
+		t.Error("Expected an error when removing a file that is not a session")
+	}
+}
+
+func TestSweepSessionsRemovesSessionsOfExitedProcesses(t *testing.T) {
+	kubetest.WriteKubeConfig(t, manageKubeConfig)
+
+	alive, err := CreateSession("dev", os.Getpid())
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	defer RemoveSession(alive.Path)
+	exited, err := CreateSession("dev", os.Getpid())
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	defer RemoveSession(exited.Path)
+
+	// PIDs are far below this on every supported system
+	if err := os.WriteFile(filepath.Join(exited.Dir, "owner"), []byte(strconv.Itoa(1<<30)), 0600); err != nil {
+		t.Fatalf("Failed to write owner: %v", err)
+	}
+
+	sweepSessions()
+
+	if !FileExists(alive.Dir) {
+		t.Error("Expected the session of a running process to be kept")
+	}
+	if FileExists(exited.Dir) {
+		t.Error("Expected the session of an exited process to be removed")
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	kubetest.WriteKubeConfig(t, manageKubeConfig)
+	session := startTestSession(t, "dev")
+
+	if sessionExpired(session.Dir) {
+		t.Error("Expected a new session not to be expired")
+	}
+
+	old := time.Now().Add(-sessionMaxAge - time.Hour)
+	if err := os.Chtimes(session.Path, old, old); err != nil {
+		t.Fatalf("Failed to age session: %v", err)
+	}
+	if !sessionExpired(session.Dir) {
+		t.Error("Expected a session unused for longer than sessionMaxAge to be expired")
+	}
+
+	// A switch inside the session keeps it alive
+	if err := SetCurrentContext("prod"); err != nil {
+		t.Fatalf("Failed to switch context: %v", err)
+	}
+	if sessionExpired(session.Dir) {
+		t.Error("Expected a switch to renew the session")
+	}
+}
+
+func TestCreateSessionUnknownContext(t *testing.T) {
+	kubetest.WriteKubeConfig(t, manageKubeConfig)
+	if _, err := CreateSession("missing", os.Getpid()); err == nil {
+		t.Error("Expected an error for a missing context")
+	}
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings is the kubec configuration file, e.g.
+//
+//	sessions: true
+//	contexts:
+//	- match: "*-prod"
+//	  protected: true
+//	  env:
+//	    AWS_PROFILE: production
+type Settings struct {
+	// Sessions makes context switches in a shell with the kubec shell
+	// integration start a session instead of changing the kubeconfig
+	Sessions bool `yaml:"sessions,omitempty"`
+
+	Contexts []ContextSettings `yaml:"contexts,omitempty"`
+}
+
+// ContextSettings applies to every context whose name matches Match, a
+// pattern in which * matches any text and ? any single character
+type ContextSettings struct {
+	Match string `yaml:"match"`
+
+	// Protected contexts need a typed confirmation before kubec switches to
+	// them
+	Protected bool `yaml:"protected,omitempty"`
+
+	// Env is exported into the shell while a matching context is current
+	Env map[string]string `yaml:"env,omitempty"`
+}
+
+func GetSettingsPath() (string, error) {
+	configDir, err := GetConfigDirectory()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "config.yaml"), nil
+}
+
+// LoadSettings reads the kubec configuration file. A missing file gives
+// empty settings.
+func LoadSettings() (*Settings, error) {
+	settings := &Settings{}
+	settingsPath, err := GetSettingsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(settingsPath)
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubec config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, settings); err != nil {
+		return nil, fmt.Errorf("failed to parse kubec config %s: %w", settingsPath, err)
+	}
+	return settings, nil
+}
+
+// ContextEnv returns the environment variables for a context. When several
+// entries match, later entries override earlier ones.
+func (s *Settings) ContextEnv(contextName string) map[string]string {
+	env := make(map[string]string)
+	for _, context := range s.Contexts {
+		if !MatchPattern(context.Match, contextName) {
+			continue
+		}
+		for name, value := range context.Env {
+			env[name] = value
+		}
+	}
+	return env
+}
+
+// ContextEnvChanges returns the environment changes for switching from one
+// context to another: variables of the old context are unset unless the new
+// context sets them too, and variables of the new context are set where the
+// current environment differs.
+func (s *Settings) ContextEnvChanges(from, to string) []EnvChange {
+	previous := s.ContextEnv(from)
+	next := s.ContextEnv(to)
+
+	var changes []EnvChange
+	for _, name := range sortedNames(previous) {
+		if _, ok := next[name]; ok {
+			continue
+		}
+		if _, set := os.LookupEnv(name); set {
+			changes = append(changes, EnvChange{Name: name, Unset: true})
+		}
+	}
+	for _, name := range sortedNames(next) {
+		if value, set := os.LookupEnv(name); !set || value != next[name] {
+			changes = append(changes, EnvChange{Name: name, Value: next[name]})
+		}
+	}
+	return changes
+}
+
+// MatchPattern matches a name against a pattern in which * matches any text,
+// including "/" and ":" as found in EKS context names, and ? matches any
+// single character
+func MatchPattern(pattern, name string) bool {
+	expression := regexp.QuoteMeta(pattern)
+	expression = strings.ReplaceAll(expression, `\*`, ".*")
+	expression = strings.ReplaceAll(expression, `\?`, ".")
+	matched, err := regexp.MatchString("^"+expression+"$", name)
+	return err == nil && matched
+}
+
+func sortedNames(values map[string]string) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSettings(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	settings, err := LoadSettings()
+	if err != nil || settings.Sessions || len(settings.Contexts) != 0 {
+		t.Fatalf("Expected empty settings without a config file, but got %+v (%v)", settings, err)
+	}
+
+	content := `sessions: true
+contexts:
+- match: "*"
+  env:
+    AWS_REGION: eu-west-1
+- match: "arn:aws:eks:*:cluster/payments-*"
+  env:
+    AWS_PROFILE: payments
+`
+	settingsPath, err := GetSettingsPath()
+	if err != nil {
+		t.Fatalf("Failed to get settings path: %v", err)
+	}
+	if settingsPath != filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "kubec", "config.yaml") {
+		t.Errorf("Unexpected settings path %s", settingsPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0700); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	if err := os.WriteFile(settingsPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	settings, err = LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if !settings.Sessions || len(settings.Contexts) != 2 {
+		t.Errorf("Unexpected settings: %+v", settings)
+	}
+
+	payments := "arn:aws:eks:eu-west-1:123456789012:cluster/payments-prod"
+	env := settings.ContextEnv(payments)
+	if env["AWS_PROFILE"] != "payments" || env["AWS_REGION"] != "eu-west-1" {
+		t.Errorf("Expected both entries to apply to %s, but got %v", payments, env)
+	}
+
+	// Only differences to the current environment are changed
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_PROFILE", "payments")
+	changes := settings.ContextEnvChanges(payments, "minikube")
+	if len(changes) != 1 || changes[0] != (EnvChange{Name: "AWS_PROFILE", Unset: true}) {
+		t.Errorf("Expected AWS_PROFILE to be unset, but got %+v", changes)
+	}
+	t.Setenv("AWS_PROFILE", "other")
+	changes = settings.ContextEnvChanges("minikube", payments)
+	if len(changes) != 1 || changes[0] != (EnvChange{Name: "AWS_PROFILE", Value: "payments"}) {
+		t.Errorf("Expected AWS_PROFILE to be set, but got %+v", changes)
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		expected      bool
+	}{
+		{"prod", "prod", true},
+		{"prod", "prod-eu", false},
+		{"*-prod", "payments-prod", true},
+		{"*prod*", "arn:aws:eks:eu-west-1:1:cluster/prod", true},
+		{"gke_?", "gke_a", true},
+		{"a.b", "axb", false},
+	}
+	for _, test := range tests {
+		if MatchPattern(test.pattern, test.name) != test.expected {
+			t.Errorf("Expected MatchPattern(%q, %q) to be %v", test.pattern, test.name, test.expected)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// EnvChange sets or unsets one environment variable of the calling shell
+type EnvChange struct {
+	Name  string
+	Value string
+	Unset bool
+}
+
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Set by the shell integration for every kubec run: the file whose commands
+// the calling shell evaluates afterwards, and the name and process ID of that
+// shell
+const (
+	ShellHookEnv = "KUBEC_SHELL_HOOK"
+	ShellNameEnv = "KUBEC_SHELL"
+	ShellPIDEnv  = "KUBEC_SHELL_PID"
+)
+
+// ShellHookActive reports whether kubec runs under the shell integration and
+// can change the environment of the calling shell
+func ShellHookActive() bool {
+	return os.Getenv(ShellHookEnv) != ""
+}
+
+// ShellPID returns the process ID of the calling shell. The parent process is
+// not always the shell: kubec may run in a subshell such as the one of
+// eval "$(kubec env prod)", which exits right away. Without the shell
+// integration the parent process is all there is to go by.
+func ShellPID() int {
+	if pid, err := strconv.Atoi(os.Getenv(ShellPIDEnv)); err == nil && pid > 0 {
+		return pid
+	}
+	return os.Getppid()
+}
+
+// WriteShellHook queues environment changes for the calling shell. It does
+// nothing and returns false without the shell integration.
+func WriteShellHook(changes []EnvChange) (bool, error) {
+	path := os.Getenv(ShellHookEnv)
+	if path == "" {
+		return false, nil
+	}
+	shell := os.Getenv(ShellNameEnv)
+	if shell == "" {
+		shell = DetectShell()
+	}
+
+	commands, err := FormatEnv(shell, changes)
+	if err != nil {
+		return false, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return false, fmt.Errorf("failed to open shell hook: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(commands); err != nil {
+		return false, fmt.Errorf("failed to write shell hook: %w", err)
+	}
+	return true, nil
+}
+
+var shellWrappers = map[string]string{
+	"bash": posixWrapper,
+	"zsh":  posixWrapper,
+	"fish": fishWrapper,
+}
+
+// The wrapper runs kubec with a hook file and evaluates what kubec wrote to
+// it in the calling shell. $$ is the PID of the shell even in a subshell. The
+// exit status is not kept in "status", which is read-only in zsh.
+const posixWrapper = `# kubec shell integration
+kubec() {
+  local hook kubec_status
+  hook="$(mktemp "${TMPDIR:-/tmp}/kubec-hook.XXXXXX")" || return
+  KUBEC_SHELL_HOOK="$hook" KUBEC_SHELL=%s KUBEC_SHELL_PID=$$ command kubec "$@"
+  kubec_status=$?
+  if [ -s "$hook" ]; then
+    . "$hook"
+  fi
+  rm -f "$hook"
+  return $kubec_status
+}
+`
+
+const fishWrapper = `# kubec shell integration
+function kubec --wraps kubec --description 'Switch Kubernetes contexts'
+    set -l tmpdir /tmp
+    set -q TMPDIR; and set tmpdir $TMPDIR
+    set -l hook (mktemp "$tmpdir/kubec-hook.XXXXXX"); or return
+    env KUBEC_SHELL_HOOK=$hook KUBEC_SHELL=fish KUBEC_SHELL_PID=$fish_pid kubec $argv
+    set -l kubec_status $status
+    if test -s $hook
+        source $hook
+    end
+    rm -f $hook
+    return $kubec_status
+end
+`
+
+// ShellWrapper returns the kubec function for a shell
+func ShellWrapper(shell string) (string, error) {
+	wrapper, ok := shellWrappers[shell]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell '%s', use %s", shell, strings.Join(Shells, ", "))
+	}
+	if shell == "fish" {
+		return wrapper, nil
+	}
+	return fmt.Sprintf(wrapper, shell), nil
+}
+
+// DetectShell returns the name of the user's shell from $SHELL, bash when it
+// is not one kubec supports
+func DetectShell() string {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	for _, supported := range Shells {
+		if shell == supported {
+			return shell
+		}
+	}
+	return "bash"
+}
+
+// FormatEnv renders environment changes as commands for shell to evaluate
+func FormatEnv(shell string, changes []EnvChange) (string, error) {
+	var lines []string
+	for _, change := range changes {
+		switch shell {
+		case "bash", "zsh", "sh":
+			if change.Unset {
+				lines = append(lines, "unset "+change.Name)
+			} else {
+				lines = append(lines, fmt.Sprintf("export %s=%s", change.Name, quotePosix(change.Value)))
+			}
+		case "fish":
+			if change.Unset {
+				lines = append(lines, fmt.Sprintf("set -e %s;", change.Name))
+			} else {
+				lines = append(lines, fmt.Sprintf("set -gx %s %s;", change.Name, quoteFish(change.Value)))
+			}
+		default:
+			return "", fmt.Errorf("unsupported shell '%s', use %s", shell, strings.Join(Shells, ", "))
+		}
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// ApplyEnv returns environ with the changes applied, for starting a process
+func ApplyEnv(environ []string, changes []EnvChange) []string {
+	result := make([]string, 0, len(environ)+len(changes))
+	changed := make(map[string]bool)
+	for _, change := range changes {
+		changed[change.Name] = true
+	}
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if !changed[name] {
+			result = append(result, entry)
+		}
+	}
+	for _, change := range changes {
+		if !change.Unset {
+			result = append(result, change.Name+"="+change.Value)
+		}
+	}
+	return result
+}
+
+// Env is the environment of a shell running the session
+func (s *Session) Env() []EnvChange {
+	return []EnvChange{
+		{Name: "KUBECONFIG", Value: s.KubeConfig},
+		{Name: SessionEnv, Value: s.Path},
+		{Name: OriginalKubeConfigEnv, Value: s.OriginalKubeConfig},
+	}
+}
+
+// EndSessionEnv restores the environment from before the current session
+func EndSessionEnv() []EnvChange {
+	restore := EnvChange{Name: "KUBECONFIG", Value: os.Getenv(OriginalKubeConfigEnv)}
+	if restore.Value == "" {
+		restore.Unset = true
+	}
+	return []EnvChange{
+		restore,
+		{Name: SessionEnv, Unset: true},
+		{Name: OriginalKubeConfigEnv, Unset: true},
+	}
+}
+
+// quotePosix single-quotes a value, ending and reopening the quotes around
+// every ' inside it
+func quotePosix(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// quoteFish single-quotes a value, fish only escapes \ and ' inside quotes
+func quoteFish(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+}
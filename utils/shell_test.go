@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatEnv(t *testing.T) {
+	changes := []EnvChange{
+		{Name: "KUBECONFIG", Value: "/tmp/it's here:/home/me/.kube/config"},
+		{Name: "KUBEC_SESSION", Unset: true},
+	}
+
+	tests := map[string]string{
+		"bash": "export KUBECONFIG='/tmp/it'\\''s here:/home/me/.kube/config'\nunset KUBEC_SESSION\n",
+		"zsh":  "export KUBECONFIG='/tmp/it'\\''s here:/home/me/.kube/config'\nunset KUBEC_SESSION\n",
+		"fish": "set -gx KUBECONFIG '/tmp/it\\'s here:/home/me/.kube/config';\nset -e KUBEC_SESSION;\n",
+	}
+	for shell, expected := range tests {
+		output, err := FormatEnv(shell, changes)
+		if err != nil {
+			t.Fatalf("Failed to format for %s: %v", shell, err)
+		}
+		if output != expected {
+			t.Errorf("Expected %s output:\n%s\nbut got:\n%s", shell, expected, output)
+		}
+	}
+
+	if _, err := FormatEnv("powershell", changes); err == nil {
+		t.Error("Expected an error for an unsupported shell")
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	environ := []string{"HOME=/home/me", "KUBECONFIG=/old", "KUBEC_SESSION=/tmp/s"}
+	result := ApplyEnv(environ, []EnvChange{
+		{Name: "KUBECONFIG", Value: "/new"},
+		{Name: "KUBEC_SESSION", Unset: true},
+	})
+	if strings.Join(result, " ") != "HOME=/home/me KUBECONFIG=/new" {
+		t.Errorf("Unexpected environment: %v", result)
+	}
+}
+
+func TestShellWrapperAppliesHook(t *testing.T) {
+	// A stand-in for the kubec binary that queues an environment change
+	binDir := t.TempDir()
+	stub := "#!/bin/sh\necho \"export KUBEC_TEST=$KUBEC_SHELL:$1 KUBEC_TEST_PID=$KUBEC_SHELL_PID\" >> \"$KUBEC_SHELL_HOOK\"\nexit 3\n"
+	if err := os.WriteFile(filepath.Join(binDir, "kubec"), []byte(stub), 0755); err != nil {
+		t.Fatalf("Failed to write kubec stub: %v", err)
+	}
+
+	for _, shell := range []string{"bash", "zsh"} {
+		t.Run(shell, func(t *testing.T) {
+			path, err := exec.LookPath(shell)
+			if err != nil {
+				t.Skipf("%s is not installed", shell)
+			}
+
+			wrapper, err := ShellWrapper(shell)
+			if err != nil {
+				t.Fatalf("Failed to get wrapper: %v", err)
+			}
+			// In a command substitution the PID is still that of the shell
+			script := wrapper + "\nkubec prod; echo \"status=$? test=$KUBEC_TEST\"\n" +
+				"[ \"$(kubec env >/dev/null; echo $KUBEC_TEST_PID)\" = $$ ] && echo same-pid\n"
+
+			// -f keeps zsh from reading the user's startup files
+			cmd := exec.Command(path, "-f", "-c", script)
+			if shell == "bash" {
+				cmd = exec.Command(path, "--norc", "-c", script)
+			}
+			cmd.Env = append(os.Environ(), "PATH="+binDir+string(filepath.ListSeparator)+os.Getenv("PATH"), "TMPDIR="+t.TempDir())
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("Failed to run wrapper: %v\n%s", err, output)
+			}
+			if expected := "status=3 test=" + shell + ":prod\nsame-pid"; strings.TrimSpace(string(output)) != expected {
+				t.Errorf("Expected %q, but got %q", expected, output)
+			}
+		})
+	}
+}
+
+func TestWriteShellHook(t *testing.T) {
+	t.Setenv(ShellHookEnv, "")
+	if applied, err := WriteShellHook([]EnvChange{{Name: "A", Value: "1"}}); applied || err != nil {
+		t.Errorf("Expected nothing to happen without the shell integration, but got %v %v", applied, err)
+	}
+
+	hook := filepath.Join(t.TempDir(), "hook")
+	t.Setenv(ShellHookEnv, hook)
+	t.Setenv(ShellNameEnv, "fish")
+	for _, change := range []EnvChange{{Name: "A", Value: "1"}, {Name: "B", Unset: true}} {
+		if applied, err := WriteShellHook([]EnvChange{change}); !applied || err != nil {
+			t.Fatalf("Failed to write hook: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(hook)
+	if err != nil {
+		t.Fatalf("Failed to read hook: %v", err)
+	}
+	if string(data) != "set -gx A '1';\nset -e B;\n" {
+		t.Errorf("Unexpected hook content:\n%s", data)
+	}
+}
+
+func TestShellPID(t *testing.T) {
+	t.Setenv(ShellPIDEnv, "4242")
+	if pid := ShellPID(); pid != 4242 {
+		t.Errorf("Expected the PID exported by the shell integration, but got %d", pid)
+	}
+
+	t.Setenv(ShellPIDEnv, "")
+	if pid := ShellPID(); pid != os.Getppid() {
+		t.Errorf("Expected the parent PID without the shell integration, but got %d", pid)
+	}
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// ContextStatus combines what kubec knows about a context: whether its
+// cluster is reachable, when its credentials expire and when it was last used
+type ContextStatus struct {
+	Context   string            `json:"context"`
+	Current   bool              `json:"current"`
+	Namespace string            `json:"namespace,omitempty"`
+	Check     ClusterCheck      `json:"check"`
+	Expiry    *CredentialExpiry `json:"expiry,omitempty"`
+	LastUsed  time.Time         `json:"lastUsed"`
+}
+
+func GetContextStatuses(ctx context.Context, timeout time.Duration, parallelism int) ([]ContextStatus, error) {
+	return DefaultClient().ContextStatuses(ctx, timeout, parallelism)
+}
+
+// ContextStatuses checks the clusters of all contexts, as CheckClusters
+// does, and returns their status in alphabetical order
+func (c *Client) ContextStatuses(ctx context.Context, timeout time.Duration, parallelism int) ([]ContextStatus, error) {
+	config, err := c.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expiries, err := c.CredentialExpiries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Without history no context has a last use
+	history, _ := GetHistory()
+
+	var names []string
+	for _, context := range config.Contexts {
+		names = append(names, context.Name)
+	}
+	sort.Strings(names)
+
+	checks := CheckClusters(ctx, config, names, timeout, parallelism)
+	return contextStatuses(config, checks, expiries, GetLastUsed(history)), nil
+}
+
+// contextStatuses puts together one status per check. expiries must be
+// ordered as GetCredentialExpiries orders them, so the first one of a context
+// is the one that needs attention first.
+func contextStatuses(config *KubeConfig, checks []ClusterCheck, expiries []CredentialExpiry, lastUsed map[string]time.Time) []ContextStatus {
+	firstExpiry := make(map[string]*CredentialExpiry)
+	for i := range expiries {
+		if _, ok := firstExpiry[expiries[i].Context]; !ok {
+			firstExpiry[expiries[i].Context] = &expiries[i]
+		}
+	}
+
+	statuses := make([]ContextStatus, len(checks))
+	for i, check := range checks {
+		status := ContextStatus{
+			Context:  check.Context,
+			Current:  check.Context == config.CurrentContext,
+			Check:    check,
+			Expiry:   firstExpiry[check.Context],
+			LastUsed: lastUsed[check.Context],
+		}
+		if context := config.FindContext(check.Context); context != nil {
+			status.Namespace = context.Context.Namespace
+		}
+		statuses[i] = status
+	}
+	return statuses
+}
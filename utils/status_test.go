@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ryo-nabata/kubec/internal/kubetest"
+)
+
+func TestContextStatuses(t *testing.T) {
+	config := &KubeConfig{
+		CurrentContext: "prod",
+		Contexts: []Context{
+			{Name: "dev", Context: ContextInfo{Namespace: "app"}},
+			{Name: "prod"},
+		},
+	}
+	checks := []ClusterCheck{{Context: "dev", Ready: true}, {Context: "prod", ErrorKind: CheckErrorTimeout}}
+	soon := time.Now().Add(time.Hour)
+	expiries := []CredentialExpiry{
+		{Context: "dev", Credential: "client certificate", NotAfter: soon},
+		{Context: "dev", Credential: "certificate authority", NotAfter: soon.Add(time.Hour)},
+	}
+	used := time.Now().Add(-time.Minute)
+
+	statuses := contextStatuses(config, checks, expiries, map[string]time.Time{"prod": used})
+
+	dev, prod := statuses[0], statuses[1]
+	if dev.Current || dev.Namespace != "app" || dev.Expiry == nil || dev.Expiry.Credential != "client certificate" || !dev.LastUsed.IsZero() {
+		t.Errorf("Unexpected status for dev: %+v", dev)
+	}
+	if !prod.Current || prod.Expiry != nil || !prod.LastUsed.Equal(used) || prod.Check.ErrorKind != CheckErrorTimeout {
+		t.Errorf("Unexpected status for prod: %+v", prod)
+	}
+}
+
+func TestGetContextStatuses(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	server := newHealthTestServer(t, "test-token", healthyHandler)
+
+	kubetest.WriteKubeConfig(t, marshalTestKubeConfig(t, *checkTestConfig([]*httptest.Server{server, server}, "test-token")))
+
+	statuses, err := GetContextStatuses(context.Background(), 5*time.Second, 4)
+	if err != nil {
+		t.Fatalf("Failed to get statuses: %v", err)
+	}
+	if len(statuses) != 2 || statuses[0].Context != "ctx-0" || !statuses[1].Check.Healthy() || statuses[1].Check.Version != "v1.30.2" {
+		t.Errorf("Unexpected statuses %+v", statuses)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !unix
+
+package utils
+
+import (
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// StdinReader reads a terminal. Without poll a read that is already waiting
+// cannot be given up, so it may still take one key after Close.
+type StdinReader struct {
+	file   *os.File
+	closed atomic.Bool
+}
+
+func NewStdinReader(file *os.File) *StdinReader {
+	return &StdinReader{file: file}
+}
+
+func (r *StdinReader) Read(p []byte) (int, error) {
+	if r.closed.Load() {
+		return 0, io.EOF
+	}
+	return r.file.Read(p)
+}
+
+func (r *StdinReader) Close() error {
+	r.closed.Store(true)
+	return nil
+}
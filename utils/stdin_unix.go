@@ -0,0 +1,56 @@
+//go:build unix
+
+package utils
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// How often a waiting Read checks whether the reader was closed
+const stdinPollMillis = 50
+
+// StdinReader reads a terminal without leaving a read pending. Once it is
+// closed the next reader of the terminal, e.g. a prompt, gets all input.
+type StdinReader struct {
+	file *os.File
+	stop chan struct{}
+	once sync.Once
+}
+
+func NewStdinReader(file *os.File) *StdinReader {
+	return &StdinReader{file: file, stop: make(chan struct{})}
+}
+
+// Read waits until there is input and reads it, or returns io.EOF once the
+// reader is closed
+func (r *StdinReader) Read(p []byte) (int, error) {
+	fds := []unix.PollFd{{Fd: int32(r.file.Fd()), Events: unix.POLLIN}}
+	for {
+		select {
+		case <-r.stop:
+			return 0, io.EOF
+		default:
+		}
+
+		n, err := unix.Poll(fds, stdinPollMillis)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if n > 0 {
+			return r.file.Read(p)
+		}
+	}
+}
+
+func (r *StdinReader) Close() error {
+	r.once.Do(func() { close(r.stop) })
+	return nil
+}
@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// StoreFile is one kubeconfig file as kept by a Store
+type StoreFile struct {
+	Path string
+	Data []byte
+}
+
+// Store keeps the kubeconfig files kubec works on. Edits load the files, change
+// one of them in memory and save it while holding its lock.
+type Store interface {
+	// Load returns the files that exist in order of precedence, or an error
+	// matching ErrKubeconfigNotFound when there are none
+	Load(ctx context.Context) ([]StoreFile, error)
+
+	// Read returns the content of one file, or an error matching
+	// ErrKubeconfigNotFound when it does not exist
+	Read(ctx context.Context, path string) ([]byte, error)
+
+	// Save replaces the content of one file
+	Save(ctx context.Context, path string, data []byte) error
+
+	// Lock takes the lock of one file and returns the function releasing it
+	Lock(ctx context.Context, path string) (func(), error)
+}
+
+// FileStore keeps kubeconfig files on disk, with the atomic writes and lock
+// files described in WriteFileAtomic and LockFile
+type FileStore struct {
+	paths []string
+}
+
+// NewFileStore returns a store for the given files. Without paths the files
+// come from KUBECONFIG or ~/.kube/config, looked up on every Load.
+func NewFileStore(paths ...string) *FileStore {
+	return &FileStore{paths: paths}
+}
+
+func (s *FileStore) Paths() ([]string, error) {
+	if len(s.paths) > 0 {
+		return s.paths, nil
+	}
+	return GetKubeConfigPaths()
+}
+
+func (s *FileStore) Load(ctx context.Context) ([]StoreFile, error) {
+	paths, err := s.Paths()
+	if err != nil {
+		return nil, err
+	}
+
+	// Missing files are skipped, the same as kubectl does
+	var files []StoreFile
+	for _, path := range paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubeconfig file: %w", err)
+		}
+		files = append(files, StoreFile{Path: path, Data: data})
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrKubeconfigNotFound, strings.Join(paths, string(filepath.ListSeparator)))
+	}
+	return files, nil
+}
+
+func (s *FileStore) Read(ctx context.Context, path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrKubeconfigNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig file: %w", err)
+	}
+	return data, nil
+}
+
+// Save writes a file, creating its directory when the file is new
+func (s *FileStore) Save(ctx context.Context, path string, data []byte) error {
+	if err := CreateDirectoryIfNotExists(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	// Never leave credentials readable by others
+	maxPerm := os.FileMode(0777)
+	if doc, err := parseKubeConfigDocument(path, data); err != nil || hasSecrets(doc.Config) {
+		maxPerm = 0600
+	}
+
+	if err := WriteFileAtomic(path, data, maxPerm); err != nil {
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) Lock(ctx context.Context, path string) (func(), error) {
+	return lockFile(ctx, path)
+}
+
+// MemoryStore keeps kubeconfig files in memory, e.g. for tests or for
+// kubeconfigs that never touch the disk
+type MemoryStore struct {
+	mu    sync.Mutex
+	files []StoreFile
+	locks map[string]chan struct{}
+}
+
+// NewMemoryStore returns a store holding files in order of precedence
+func NewMemoryStore(files ...StoreFile) *MemoryStore {
+	s := &MemoryStore{locks: make(map[string]chan struct{})}
+	for _, file := range files {
+		s.files = append(s.files, StoreFile{Path: file.Path, Data: append([]byte(nil), file.Data...)})
+	}
+	return s
+}
+
+func (s *MemoryStore) Load(ctx context.Context) ([]StoreFile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.files) == 0 {
+		return nil, fmt.Errorf("%w: the store is empty", ErrKubeconfigNotFound)
+	}
+	files := make([]StoreFile, len(s.files))
+	for i, file := range s.files {
+		files[i] = StoreFile{Path: file.Path, Data: append([]byte(nil), file.Data...)}
+	}
+	return files, nil
+}
+
+func (s *MemoryStore) Read(ctx context.Context, path string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, file := range s.files {
+		if file.Path == path {
+			return append([]byte(nil), file.Data...), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrKubeconfigNotFound, path)
+}
+
+// Save replaces a file, or adds it after the existing files
+func (s *MemoryStore) Save(ctx context.Context, path string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data = append([]byte(nil), data...)
+	for i := range s.files {
+		if s.files[i].Path == path {
+			s.files[i].Data = data
+			return nil
+		}
+	}
+	s.files = append(s.files, StoreFile{Path: path, Data: data})
+	return nil
+}
+
+// Lock waits until no one else holds the lock of path or ctx is done
+func (s *MemoryStore) Lock(ctx context.Context, path string) (func(), error) {
+	s.mu.Lock()
+	lock, ok := s.locks[path]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		s.locks[path] = lock
+	}
+	s.mu.Unlock()
+
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to lock %s: %w", path, ctx.Err())
+	}
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStoreLoad(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	missing := filepath.Join(dir, "missing")
+	if err := os.WriteFile(first, []byte("kind: Config\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	files, err := NewFileStore(missing, first).Load(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != first || string(files[0].Data) != "kind: Config\n" {
+		t.Errorf("Expected only %s, but got %v", first, files)
+	}
+
+	if _, err := NewFileStore(missing).Load(context.Background()); !errors.Is(err, ErrKubeconfigNotFound) {
+		t.Errorf("Expected ErrKubeconfigNotFound, but got %v", err)
+	}
+	if _, err := NewFileStore(first).Read(context.Background(), missing); !errors.Is(err, ErrKubeconfigNotFound) {
+		t.Errorf("Expected ErrKubeconfigNotFound when reading a missing file, but got %v", err)
+	}
+}
+
+func TestFileStoreSaveRestrictsSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("kind: Config\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	store := NewFileStore(path)
+	if err := store.Save(context.Background(), path, []byte("kind: Config\nusers:\n- name: u\n  user:\n    token: secret\n")); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600 for a file with a token, but got %o", info.Mode().Perm())
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(StoreFile{Path: "a", Data: []byte("kind: Config\n")})
+
+	files, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	// Loaded data is a copy
+	files[0].Data[0] = 'K'
+
+	if err := store.Save(context.Background(), "b", []byte("apiVersion: v1\n")); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	files, _ = store.Load(context.Background())
+	if len(files) != 2 || string(files[0].Data) != "kind: Config\n" || files[1].Path != "b" {
+		t.Errorf("Unexpected files %v", files)
+	}
+
+	if data, err := store.Read(context.Background(), "b"); err != nil || string(data) != "apiVersion: v1\n" {
+		t.Errorf("Expected to read b, but got %q %v", data, err)
+	}
+	if _, err := store.Read(context.Background(), "c"); !errors.Is(err, ErrKubeconfigNotFound) {
+		t.Errorf("Expected ErrKubeconfigNotFound for a missing file, but got %v", err)
+	}
+
+	if _, err := NewMemoryStore().Load(context.Background()); !errors.Is(err, ErrKubeconfigNotFound) {
+		t.Errorf("Expected ErrKubeconfigNotFound, but got %v", err)
+	}
+}
+
+func TestMemoryStoreLockHonorsContext(t *testing.T) {
+	store := NewMemoryStore()
+	unlock, err := store.Lock(context.Background(), "a")
+	if err != nil {
+		t.Fatalf("Failed to lock: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := store.Lock(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the second lock to time out, but got %v", err)
+	}
+
+	unlock()
+	unlockAgain, err := store.Lock(context.Background(), "a")
+	if err != nil {
+		t.Fatalf("Failed to lock after unlock: %v", err)
+	}
+	unlockAgain()
+}
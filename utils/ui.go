@@ -2,6 +2,9 @@ package utils
 
 import (
 	"fmt"
+	"io"
+	"os"
+
 	"github.com/fatih/color"
 )
 
@@ -18,7 +21,19 @@ func PrintInfo(message string) {
 }
 
 func PrintWarning(message string) {
-	fmt.Printf("⚠ %s\n", color.YellowString(message))
+	FprintWarning(os.Stdout, message)
+}
+
+// FprintWarning is PrintWarning to another writer, e.g. stderr when a shell
+// evaluates stdout
+func FprintWarning(w io.Writer, message string) {
+	fmt.Fprintf(w, "⚠ %s\n", color.YellowString(message))
+}
+
+// FprintDanger is FprintWarning for warnings that must not be missed, with
+// the message in white on red instead of yellow
+func FprintDanger(w io.Writer, message string) {
+	fmt.Fprintf(w, "⚠ %s\n", color.New(color.BgRed, color.FgWhite, color.Bold).Sprint(message))
 }
 
 func PrintHeader(title string) {